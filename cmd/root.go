@@ -3,7 +3,11 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/manifoldco/promptui"
@@ -19,50 +23,109 @@ import (
 // Run initializes and executes the command-line interface for the tool/application.
 //
 // It defines CLI flags, handles user input (with interactive prompts if flags are missing),
-// loads EC2 and Terraform data, and invokes the drift detection engine.
-func Run() {
+// loads EC2 and Terraform data, and invokes the drift detection engine. Errors are returned rather
+// than ending the process, so a run can fail cleanly (e.g. when the AWS configuration cannot be
+// loaded) and be driven from tests; drift is returned as a cli.ExitCoder with exit code 2.
+func Run(args []string) error {
 	ctx := context.Background()
 
 	// init the logger
 	logger := zerolog.New(os.Stderr).With().Timestamp().Str("app", "drift-checker").Logger()
 
 	// init all services.
-	tfSvc := tf.NewParser(ctx, logger)            // terraform service
-	ec2Svc, err := aws.NewEC2Service(ctx, logger) // aws service
-	if err != nil {
-		logger.Fatal().Err(err).Msg("failed to initialize aws service")
-		return
-	}
+	tfSvc := tf.NewParser(ctx, logger)        // terraform service
+	cfgSvc := tf.NewConfigParser(ctx, logger) // terraform configuration (HCL) service
 
 	app := &cli.App{
 		Name:  "drift-checker",
 		Usage: "Detect drift between AWS EC2 instances and Terraform state",
+		// exit codes are left to the caller, see main
+		ExitErrHandler: func(*cli.Context, error) {},
 		Flags: []cli.Flag{
-			&cli.StringFlag{Name: "state-file", Usage: "Path to Terraform .tfstate file"},
-			&cli.StringFlag{Name: "instance-ids", Usage: "Comma-separated list of EC2 instance IDs"},
+			&cli.StringSliceFlag{Name: "state-file", Usage: "Path, glob or directory of Terraform .tfstate files (repeatable)"},
+			&cli.StringSliceFlag{Name: "state-url", Usage: "Terraform state location: s3://bucket/key[?versionId=id], tfc://org/workspace, http(s)://... or - for stdin (repeatable)"},
+			&cli.StringFlag{Name: "s3-endpoint", Usage: "Custom S3 endpoint URL (e.g. a local S3-compatible server), overriding --endpoint-url for state reads"},
+			&cli.StringFlag{Name: "tfc-organization", Usage: "Terraform Cloud/Enterprise organization to read state from"},
+			&cli.StringSliceFlag{Name: "tfc-workspace", Usage: "Terraform Cloud/Enterprise workspace to read state from (repeatable)"},
+			&cli.StringFlag{Name: "tfc-token", Usage: "Terraform Cloud/Enterprise API token", EnvVars: []string{"TFC_TOKEN", "TF_TOKEN_app_terraform_io"}},
+			&cli.StringFlag{Name: "tfc-address", Usage: "Terraform Cloud/Enterprise base URL", Value: tf.DefaultCloudAddress},
+			&cli.StringFlag{Name: "config-dir", Usage: "Directory of Terraform .tf files to compare live AWS against instead of the state"},
+			&cli.StringSliceFlag{Name: "var-file", Usage: "Terraform .tfvars file used with --config-dir (repeatable)"},
+			&cli.StringFlag{Name: "plan-file", Usage: "Location of `terraform show -json` output of a saved plan: predict drift before apply instead of reading the state"},
+			&cli.BoolFlag{Name: "three-way", Usage: "Compare configuration, state and live AWS, classifying each difference (requires --config-dir)"},
+			&cli.StringFlag{Name: "instance-ids", Usage: "Comma-separated list of EC2 instance IDs (default: every aws_instance in the state)"},
+			&cli.StringFlag{Name: "profile", Usage: "AWS shared config profile to use"},
+			&cli.StringFlag{Name: "region", Usage: "AWS region to use by default, overriding AWS_REGION and the profile"},
+			&cli.StringFlag{Name: "endpoint-url", Usage: "Custom AWS endpoint URL for every service (e.g. http://localhost:4566 for LocalStack)"},
+			&cli.IntFlag{Name: "max-retries", Usage: "Maximum number of retries of a throttled or failed AWS request, 0 to disable them (default: 5 for EC2, the SDK's otherwise)"},
+			&cli.StringFlag{Name: "record", Usage: "Directory to save every EC2 response to, to replay the run later with --replay"},
+			&cli.StringFlag{Name: "replay", Usage: "Directory of a --record run to read EC2 responses from instead of calling AWS (no credentials needed)"},
+			&cli.Float64Flag{Name: "rate-limit", Usage: "Maximum EC2 requests per second in each region and account; slows down further while AWS throttles", Value: aws.DefaultRateLimit},
+			&cli.StringSliceFlag{Name: "assume-role", Usage: "IAM role ARN to assume to scan another AWS account (repeatable, one per account)"},
+			&cli.StringFlag{Name: "external-id", Usage: "External ID passed when assuming the --assume-role roles"},
+			&cli.StringFlag{Name: "role-session-name", Usage: "Session name used when assuming the --assume-role roles", Value: aws.DefaultSessionName},
+			&cli.StringSliceFlag{Name: "regions", Usage: "AWS regions to look instances up in when the state does not record their availability zone, and to scan with --filter or --unmanaged (default: the regions of the state, else the configured region)"},
+			&cli.StringSliceFlag{Name: "filter", Usage: "DescribeInstances filter name=value[,value] choosing the live instances, e.g. tag:Env=prod, vpc-id=vpc-123 or instance-state-name=running (repeatable)"},
 			&cli.StringFlag{Name: "attributes", Usage: "Comma-separated attributes to check for drift"},
 			&cli.BoolFlag{Name: "json", Usage: "Output drift result as JSON"},
+			&cli.BoolFlag{Name: "ignore-default-tags", Usage: "Compare live tags against the resource's own tags only, not tags_all (provider default_tags)"},
+			&cli.BoolFlag{Name: "unmanaged", Usage: "List live instances (narrowed by --filter) that no loaded state manages, instead of checking drift"},
+			&cli.BoolFlag{Name: "include-data-sources", Usage: "Also report data \"aws_instance\" lookups as observed-only resources"},
 		},
 		Action: func(c *cli.Context) error {
-			// check for state file. in case no state file is provided, do a fallback and ask the user
-			stateFile := c.String("state-file")
-			if stateFile == "" {
-				stateFile, err = promptInput("Enter path to Terraform state file")
+			// check for state file. in case no state file or remote state is provided, do a fallback and ask the user
+			locations, err := stateLocations(c)
+			if err != nil {
+				return err
+			}
+			planFile := c.String("plan-file")
+			if planFile != "" {
+				// a plan names its own instances and values, these would silently be ignored
+				for _, name := range []string{"filter", "three-way", "include-data-sources", "config-dir", "unmanaged"} {
+					if c.IsSet(name) {
+						logger.Error().Str("option", name).Msg("option cannot be combined with --plan-file")
+						return common.ErrPlanFileConflict
+					}
+				}
+			}
+			if len(locations) == 0 && planFile == "" {
+				stateFile, err := promptInput("Enter path to Terraform state file")
 				if err != nil {
 					logger.Err(err).Msg("failed to prompt input")
 					return common.ErrStateFileNotProvided
 				}
+				locations = []string{stateFile}
 			}
 
-			// check for instance IDs. in case no instance IDs are provided, do a fallback and ask the user
+			// without instance IDs, every instance in the state (or touched by the plan) is checked
 			instanceIDs := common.ParseCommaList(c.String("instance-ids"))
-			if len(instanceIDs) == 0 {
-				var raw string
-				raw, err = promptInput("Enter comma-separated EC2 instance IDs")
-				if err != nil {
-					return common.ErrNoInstanceIDs
-				}
-				instanceIDs = common.ParseCommaList(raw)
+			filters, err := aws.ParseInstanceFilters(c.StringSlice("filter"))
+			if err != nil {
+				return err
+			}
+			regions := c.StringSlice("regions")
+
+			// the accounts to scan: the default credentials' account, or every account assumed into
+			clientOpts := aws.ClientOptions{
+				Profile:     c.String("profile"),
+				Region:      c.String("region"),
+				EndpointURL: c.String("endpoint-url"),
+				RateLimit:   c.Float64("rate-limit"),
+				RecordDir:   c.String("record"),
+				ReplayDir:   c.String("replay"),
+			}
+			if c.IsSet("max-retries") {
+				// an explicit 0 turns retries off, so it can't stand for "not set"
+				maxRetries := c.Int("max-retries")
+				clientOpts.MaxRetries = &maxRetries
+			}
+			if clientOpts.RecordDir != "" && clientOpts.ReplayDir != "" {
+				return common.ErrRecordAndReplay
+			}
+			ec2Svcs, err := accountServices(ctx, c, clientOpts, logger)
+			if err != nil {
+				logger.Err(err).Msg("failed to initialize aws service")
+				return err
 			}
 
 			// we need to fetch the attributes we want to compare
@@ -75,39 +138,384 @@ func Run() {
 
 			outputJSON := c.Bool("json")
 
-			// time to parse the Terraform file
-			tfInstances, err := tfSvc.Load(stateFile)
+			// time to parse the Terraform state. the parser picks the right source from the location's scheme
+			registerStateSources(ctx, c, tfSvc, clientOpts, logger)
+			if planFile != "" {
+				return checkPlan(ctx, tfSvc, ec2Svcs, planFile, instanceIDs, regions, attributeFilter, outputJSON, c.Bool("ignore-default-tags"), logger)
+			}
+
+			tfSvc.SetIncludeDataSources(c.Bool("include-data-sources"))
+			tfInstances, conflicts, err := tfSvc.LoadAll(ctx, locations)
 			if err != nil {
-				logger.Err(err).Msg("failed to load state file")
+				logger.Err(err).Msg("failed to load terraform state")
 				return err
 			}
+			if c.Bool("ignore-default-tags") {
+				dropDefaultTags(tfInstances...)
+			}
+			for _, conflict := range conflicts {
+				logger.Warn().Strs("states", conflict.Origins).Msgf("instance %s is managed by more than one state", conflict.InstanceID)
+			}
+
+			// inventory mode: everything live that none of the states accounts for
+			if c.Bool("unmanaged") {
+				liveInstances, err := aws.FindInstancesInAccounts(ctx, ec2Svcs, aws.RegionsToScan(tfInstances, regions), filters)
+				if err != nil {
+					logger.Err(err).Msg("failed to list AWS instances")
+					return lookupFailed(err, ec2Svcs, outputJSON)
+				}
+				engine.PrintUnmanagedReport(engine.FindUnmanagedInstances(liveInstances, tfInstances), outputJSON)
+				return nil
+			}
+
+			// with a configuration directory, live AWS is compared against the .tf source rather than the state.
+			// the state is still needed to know which instance each resource block created
+			var cfgInstances []*common.EC2Instance
+			if configDir := c.String("config-dir"); configDir != "" {
+				loaded, err := cfgSvc.LoadDir(configDir, c.StringSlice("var-file")...)
+				if err != nil {
+					logger.Err(err).Msg("failed to load terraform configuration")
+					return err
+				}
+				if c.Bool("ignore-default-tags") {
+					dropDefaultTags(loaded...)
+				}
+
+				for _, inst := range tf.AttachInstanceIDs(loaded, tfInstances) {
+					logger.Warn().Msgf("%s is not in the Terraform state yet (pending create), skipping", inst.Address)
+				}
+				for _, inst := range loaded {
+					if inst.InstanceID != "" {
+						cfgInstances = append(cfgInstances, inst)
+					}
+				}
+				if len(cfgInstances) == 0 {
+					logger.Error().Str("config_dir", configDir).Msg("no aws_instance block of the configuration matches an instance of the state")
+					return common.ErrNoConfigInstances
+				}
+			} else if c.Bool("three-way") {
+				return common.ErrConfigDirNotProvided
+			}
 
 			// okay, let's get on AWS
+			// lookedUp are the IDs asked for one by one: the ones AWS does not return anymore are missing.
+			// with filters, the state's instances the filters did not select are looked up by ID for that
 			var awsInstances []*common.EC2Instance
-			for _, id := range instanceIDs {
-				inst, err := ec2Svc.GetInstance(ctx, id)
-				if err != nil {
-					logger.Err(err).Msgf("warning: could not retrieve AWS instance %s: %v", id, err)
-					continue
+			var lookedUp []string
+			if len(filters) > 0 {
+				awsInstances, err = findInstances(ctx, ec2Svcs, filters, instanceIDs, aws.RegionsToScan(tfInstances, regions), logger)
+				if err == nil {
+					ids := instanceIDs
+					if len(ids) == 0 {
+						ids = stateInstanceIDs(tfInstances)
+					}
+					var terminated []*common.EC2Instance
+					terminated, lookedUp, err = lookUpUnselected(ctx, ec2Svcs, awsInstances, ids, tfInstances, regions, logger)
+					awsInstances = append(awsInstances, terminated...)
 				}
-				awsInstances = append(awsInstances, inst)
+			} else {
+				if len(instanceIDs) == 0 {
+					instanceIDs = stateInstanceIDs(tfInstances)
+				}
+				if len(instanceIDs) == 0 {
+					return common.ErrNoInstanceIDs
+				}
+				awsInstances, err = getInstances(ctx, ec2Svcs, aws.RegionsForInstances(instanceIDs, tfInstances, regions), instanceIDs, logger)
+				lookedUp = instanceIDs
+			}
+			if err != nil {
+				return lookupFailed(err, ec2Svcs, outputJSON)
 			}
 
 			// run all comparisons concurrently
-			results := engine.CompareAllInstances(context.Background(), awsInstances, tfInstances, attributeFilter)
+			var results []common.DriftResult
+			switch {
+			case c.Bool("three-way"):
+				results = engine.CompareThreeWay(ctx, awsInstances, tfInstances, cfgInstances, attributeFilter)
+			case c.String("config-dir") != "":
+				results = engine.CompareConfig(ctx, awsInstances, tfInstances, cfgInstances, attributeFilter)
+			default:
+				results = engine.CompareAllInstances(ctx, awsInstances, tfInstances, attributeFilter)
+			}
+			results = append(results, engine.FindMissingInstances(lookedUp, awsInstances, tfInstances)...)
+
+			engine.MarkConflicts(results, conflicts)
+
+			// data sources are reported separately, they never count as drift of managed resources
+			if c.Bool("include-data-sources") {
+				results = append(results, engine.CompareObservedInstances(awsInstances, tfInstances, attributeFilter)...)
+			}
 
 			// show the results
+			drifted := 0
 			for _, result := range results {
 				engine.PrintDriftReport(result, outputJSON)
+				if result.DriftDetected && !result.ObservedOnly {
+					drifted++
+				}
+			}
+
+			engine.PrintRunSummary(engine.Summarize(results, aws.TotalStats(ec2Svcs)), outputJSON)
+
+			if drifted > 0 {
+				return cli.Exit(fmt.Sprintf("drift detected for %d instance(s)", drifted), 2)
 			}
 
 			return nil
 		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		logger.Fatal().Err(err).Msg("failed to run app")
+	if err := app.Run(args); err != nil {
+		var exitErr cli.ExitCoder
+		if errors.As(err, &exitErr) {
+			fmt.Fprintln(os.Stderr, err)
+		} else {
+			logger.Err(err).Msg("failed to run app")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// checkPlan predicts drift for the instances touched by a saved plan and fails
+// with exit code 2 when any is found, so it can gate `terraform apply` in CI.
+// When instanceIDs is empty, every instance the plan touches is checked.
+func checkPlan(ctx context.Context, tfSvc tf.Parser, ec2Svcs []aws.EC2Service, planFile string, instanceIDs, regions []string, filter map[string]bool, outputJSON, ignoreDefaultTags bool, logger zerolog.Logger) error {
+	changes, err := tfSvc.LoadPlan(ctx, planFile)
+	if err != nil {
+		logger.Err(err).Msg("failed to load terraform plan")
+		return err
+	}
+	if ignoreDefaultTags {
+		for _, change := range changes {
+			dropDefaultTags(change.Before, change.After)
+		}
+	}
+
+	// only instances the plan keeps are compared (see engine.ComparePlan), replaced and deleted ones are not looked up
+	wanted := common.ToMap(instanceIDs)
+	changes = slices.DeleteFunc(changes, func(change common.PlannedChange) bool {
+		if change.Action != common.PlanActionNoOp && change.Action != common.PlanActionUpdate {
+			return true
+		}
+		return len(wanted) > 0 && !wanted[change.Before.InstanceID]
+	})
+	instanceIDs = make([]string, 0, len(changes))
+	for _, change := range changes {
+		instanceIDs = append(instanceIDs, change.Before.InstanceID)
+	}
+
+	planned := make([]*common.EC2Instance, 0, len(changes))
+	for _, change := range changes {
+		planned = append(planned, change.Before)
+	}
+
+	awsInstances, err := getInstances(ctx, ec2Svcs, aws.RegionsForInstances(instanceIDs, planned, regions), instanceIDs, logger)
+	if err != nil {
+		return lookupFailed(err, ec2Svcs, outputJSON)
+	}
+
+	results := engine.ComparePlan(awsInstances, changes, filter)
+	drifted := 0
+	for _, result := range results {
+		engine.PrintDriftReport(result, outputJSON)
+		if result.DriftDetected {
+			drifted++
+		}
 	}
+	engine.PrintRunSummary(engine.Summarize(results, aws.TotalStats(ec2Svcs)), outputJSON)
+
+	if drifted > 0 {
+		return cli.Exit(fmt.Sprintf("drift predicted for %d instance(s) - review before applying the plan", drifted), 2)
+	}
+
+	return nil
+}
+
+// getInstances describes the instances of every account and region at the same time, warning about the ones AWS
+// does not know. Those that are in the state are reported as missing_in_aws drift by engine.FindMissingInstances.
+func getInstances(ctx context.Context, ec2Svcs []aws.EC2Service, byRegion map[string][]string, instanceIDs []string, logger zerolog.Logger) ([]*common.EC2Instance, error) {
+	instances, notFound, err := aws.GetInstancesInAccounts(ctx, ec2Svcs, byRegion)
+	if err != nil {
+		logger.Err(err).Msg("failed to retrieve AWS instances")
+		return nil, err
+	}
+
+	for _, id := range instanceIDs {
+		if err, ok := notFound[id]; ok {
+			logger.Err(err).Msgf("warning: could not retrieve AWS instance %s: %v", id, err)
+		}
+	}
+
+	return instances, nil
+}
+
+// findInstances describes the instances matching the filters. Explicit instance IDs narrow them down further;
+// otherwise every match is compared, so matching instances missing from the state are reported too.
+func findInstances(ctx context.Context, ec2Svcs []aws.EC2Service, filters map[string][]string, instanceIDs, regions []string, logger zerolog.Logger) ([]*common.EC2Instance, error) {
+	instances, err := aws.FindInstancesInAccounts(ctx, ec2Svcs, regions, filters)
+	if err != nil {
+		logger.Err(err).Msg("failed to retrieve AWS instances")
+		return nil, err
+	}
+	if len(instanceIDs) == 0 {
+		return instances, nil
+	}
+
+	wanted := common.ToMap(instanceIDs)
+	return slices.DeleteFunc(instances, func(inst *common.EC2Instance) bool {
+		return !wanted[inst.InstanceID]
+	}), nil
+}
+
+// lookUpUnselected looks up by ID the instances the filters did not select, so that state instances AWS no longer
+// has are reported as missing_in_aws even when a filter would never match them. It returns the terminated ones,
+// to be reported like the selected instances, and the IDs AWS does not know at all. The others merely fall
+// outside the filters.
+func lookUpUnselected(ctx context.Context, ec2Svcs []aws.EC2Service, selected []*common.EC2Instance, instanceIDs []string, tfInstances []*common.EC2Instance, regions []string, logger zerolog.Logger) ([]*common.EC2Instance, []string, error) {
+	found := make(map[string]bool, len(selected))
+	for _, inst := range selected {
+		found[inst.InstanceID] = true
+	}
+	var unselected []string
+	for _, id := range instanceIDs {
+		if !found[id] {
+			unselected = append(unselected, id)
+		}
+	}
+	if len(unselected) == 0 {
+		return nil, nil, nil
+	}
+
+	instances, err := getInstances(ctx, ec2Svcs, aws.RegionsForInstances(unselected, tfInstances, regions), unselected, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var terminated []*common.EC2Instance
+	for _, inst := range instances {
+		found[inst.InstanceID] = true
+		if inst.State == common.InstanceStateTerminated {
+			terminated = append(terminated, inst)
+		}
+	}
+	var missing []string
+	for _, id := range unselected {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return terminated, missing, nil
+}
+
+// lookupFailed prints the run summary of a failed AWS lookup and returns err, so the requests AWS kept
+// throttling are still reported, apart from real failures, rather than lost with the run.
+func lookupFailed(err error, ec2Svcs []aws.EC2Service, outputJSON bool) error {
+	engine.PrintRunSummary(engine.Summarize(nil, aws.TotalStats(ec2Svcs)), outputJSON)
+	return err
+}
+
+// accountServices returns the EC2 services of the accounts to scan: one per --assume-role role,
+// or else the account of the default credentials.
+func accountServices(ctx context.Context, c *cli.Context, opts aws.ClientOptions, logger zerolog.Logger) ([]aws.EC2Service, error) {
+	roles := c.StringSlice("assume-role")
+	if len(roles) == 0 {
+		ec2Svc, err := aws.NewEC2Service(ctx, logger, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []aws.EC2Service{ec2Svc}, nil
+	}
+
+	profiles := make([]aws.AccountProfile, 0, len(roles))
+	for _, role := range roles {
+		profiles = append(profiles, aws.AccountProfile{
+			RoleARN:     role,
+			ExternalID:  c.String("external-id"),
+			SessionName: c.String("role-session-name"),
+		})
+	}
+
+	return aws.NewAccountEC2Services(ctx, logger, opts, profiles, nil)
+}
+
+// stateInstanceIDs lists the IDs of every instance the state manages, in state order.
+// Instances only read through a data source are left out.
+func stateInstanceIDs(instances []*common.EC2Instance) []string {
+	var ids []string
+	seen := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		if inst.InstanceID != "" && !inst.ObservedOnly && !seen[inst.InstanceID] {
+			seen[inst.InstanceID] = true
+			ids = append(ids, inst.InstanceID)
+		}
+	}
+
+	return ids
+}
+
+// dropDefaultTags forgets tags_all, so only the resources' own tags are compared.
+func dropDefaultTags(instances ...*common.EC2Instance) {
+	for _, inst := range instances {
+		if inst != nil {
+			inst.TagsAll = nil
+		}
+	}
+}
+
+// stateLocations collects every Terraform state to read: Terraform Cloud workspaces,
+// then --state-url locations, then --state-file paths. All of them are merged.
+func stateLocations(c *cli.Context) ([]string, error) {
+	var locations []string
+	workspaces := c.StringSlice("tfc-workspace")
+	if len(workspaces) > 0 && c.String("tfc-organization") == "" {
+		return nil, common.ErrCloudOrganizationNotProvided
+	}
+	for _, ws := range workspaces {
+		locations = append(locations, fmt.Sprintf("%s://%s/%s", tf.SchemeTFC, c.String("tfc-organization"), ws))
+	}
+	locations = append(locations, c.StringSlice("state-url")...)
+	locations = append(locations, c.StringSlice("state-file")...)
+
+	return locations, nil
+}
+
+// registerStateSources registers the state sources that depend on CLI flags or AWS with the parser.
+func registerStateSources(ctx context.Context, c *cli.Context, tfSvc tf.Parser, opts aws.ClientOptions, logger zerolog.Logger) {
+	tfSvc.RegisterSource(tf.SchemeTFC, tf.NewCloudClient(ctx, logger, c.String("tfc-address"), c.String("tfc-token")))
+
+	// the S3 client is only built when an s3:// location is actually loaded
+	s3Opts := opts
+	if endpoint := c.String("s3-endpoint"); endpoint != "" {
+		s3Opts.EndpointURL = endpoint
+	}
+	tfSvc.RegisterSource(tf.SchemeS3, s3Source{opts: s3Opts, logger: logger})
+}
+
+// s3Source reads state from s3:// locations, streaming it when the parser allows.
+type s3Source struct {
+	opts   aws.ClientOptions
+	logger zerolog.Logger
+}
+
+func (s s3Source) Fetch(ctx context.Context, location string) ([]byte, error) {
+	s3Svc, err := aws.NewS3Service(ctx, s.logger, s.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3Svc.GetState(ctx, location)
+}
+
+func (s s3Source) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	s3Svc, err := aws.NewS3Service(ctx, s.logger, s.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3Svc.OpenState(ctx, location)
 }
 
 // promptInput shows an interactive prompt on the CLI
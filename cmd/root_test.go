@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+
+	"github.com/odetolakehinde/drift-checker/pkg/aws"
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// noAWSConfig points the AWS SDK at a profile that does not exist, so loading its configuration fails.
+func noAWSConfig(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_PROFILE", "does-not-exist")
+}
+
+func TestRun_Replay(t *testing.T) {
+	noAWSConfig(t)
+
+	// the recording has the instance running as a t3.large, the state says t3.micro
+	err := Run([]string{"drift-checker", "--state-file=testdata/replay.tfstate", "--replay=testdata/recording"})
+
+	var exitErr cli.ExitCoder
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.ExitCode())
+	assert.Contains(t, err.Error(), "drift detected for 1 instance(s)")
+}
+
+func TestRun_AWSConfigFailure(t *testing.T) {
+	noAWSConfig(t)
+
+	err := Run([]string{"drift-checker", "--state-file=testdata/replay.tfstate"})
+	assert.ErrorIs(t, err, common.ErrConfigLoadFailure)
+
+	err = Run([]string{"drift-checker", "--state-file=testdata/replay.tfstate", "--replay=testdata/missing"})
+	assert.ErrorIs(t, err, common.ErrRecordingNotFound)
+
+	err = Run([]string{"drift-checker", "--state-file=testdata/replay.tfstate", "--replay=testdata/recording", "--record=" + t.TempDir()})
+	assert.ErrorIs(t, err, common.ErrRecordAndReplay)
+}
+
+func TestRun_TFCWorkspaceWithoutOrganization(t *testing.T) {
+	err := Run([]string{"drift-checker", "--tfc-workspace=prod", "--replay=testdata/recording"})
+	assert.ErrorIs(t, err, common.ErrCloudOrganizationNotProvided)
+}
+
+func TestRun_PlanFileConflicts(t *testing.T) {
+	for _, flag := range []string{"--filter=tag:Env=prod", "--three-way", "--include-data-sources"} {
+		err := Run([]string{"drift-checker", "--plan-file=plan.json", "--replay=testdata/recording", flag})
+		assert.ErrorIs(t, err, common.ErrPlanFileConflict, flag)
+	}
+}
+
+func TestStateInstanceIDs(t *testing.T) {
+	ids := stateInstanceIDs([]*common.EC2Instance{
+		{InstanceID: "i-a"},
+		{InstanceID: "i-lookup", ObservedOnly: true},
+		{InstanceID: "i-a"},
+		{InstanceID: "i-b"},
+	})
+	assert.Equal(t, []string{"i-a", "i-b"}, ids)
+}
+
+func TestRun_ConfigDirWithoutMatches(t *testing.T) {
+	noAWSConfig(t)
+
+	err := Run([]string{"drift-checker", "--state-file=testdata/replay.tfstate", "--replay=testdata/recording", "--config-dir=testdata/config-unmatched"})
+	assert.ErrorIs(t, err, common.ErrNoConfigInstances)
+}
+
+// liveService is an account holding the given instances.
+type liveService struct {
+	aws.EC2Service
+	instances []*common.EC2Instance
+}
+
+func (s liveService) GetInstancesInRegions(_ context.Context, byRegion map[string][]string) ([]*common.EC2Instance, map[string]error, error) {
+	var found []*common.EC2Instance
+	for _, ids := range byRegion {
+		for _, inst := range s.instances {
+			if slices.Contains(ids, inst.InstanceID) {
+				found = append(found, inst)
+			}
+		}
+	}
+	return found, nil, nil
+}
+
+func TestLookUpUnselected(t *testing.T) {
+	svcs := []aws.EC2Service{liveService{instances: []*common.EC2Instance{
+		{InstanceID: "i-selected", State: "running"},
+		{InstanceID: "i-terminated", State: common.InstanceStateTerminated},
+		{InstanceID: "i-other", State: "running"},
+	}}}
+	selected := []*common.EC2Instance{{InstanceID: "i-selected"}}
+
+	terminated, missing, err := lookUpUnselected(context.Background(), svcs, selected,
+		[]string{"i-selected", "i-terminated", "i-other", "i-deleted"}, nil, []string{"us-east-1"}, zerolog.Nop())
+	assert.NoError(t, err)
+	assert.Len(t, terminated, 1)
+	assert.Equal(t, "i-terminated", terminated[0].InstanceID)
+	assert.Equal(t, []string{"i-deleted"}, missing)
+
+	terminated, missing, err = lookUpUnselected(context.Background(), svcs, selected, []string{"i-selected"}, nil, nil, zerolog.Nop())
+	assert.NoError(t, err)
+	assert.Empty(t, terminated)
+	assert.Empty(t, missing)
+}
+
+// throttledService is an account whose every lookup ends with AWS still throttling.
+type throttledService struct {
+	aws.EC2Service
+}
+
+func (throttledService) GetInstancesInRegions(context.Context, map[string][]string) ([]*common.EC2Instance, map[string]error, error) {
+	return nil, nil, common.ErrAWSThrottled
+}
+
+func (throttledService) Stats() common.APICallStats {
+	return common.APICallStats{Requests: 6, Retries: 5, Throttled: 6, ThrottleFailures: 1}
+}
+
+func TestLookupFailed_PrintsSummary(t *testing.T) {
+	svcs := []aws.EC2Service{throttledService{}}
+
+	var err error
+	output := captureStdout(t, func() {
+		_, err = getInstances(context.Background(), svcs, map[string][]string{"": {"i-a"}}, []string{"i-a"}, zerolog.Nop())
+		err = lookupFailed(err, svcs, false)
+	})
+
+	assert.ErrorIs(t, err, common.ErrAWSThrottled)
+	assert.Contains(t, output, "Throttled:         6")
+	assert.Contains(t, output, "1 request(s) were still throttled after every retry")
+}
+
+// captureStdout returns what f prints to standard output.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	assert.NoError(t, w.Close())
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
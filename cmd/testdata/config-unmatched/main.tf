@@ -0,0 +1,4 @@
+resource "aws_instance" "not_applied" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+}
@@ -1,9 +1,23 @@
 // Package main is the entry point of the application
 package main
 
-import "github.com/odetolakehinde/drift-checker/cmd"
+import (
+	"errors"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/odetolakehinde/drift-checker/cmd"
+)
 
 // main is the entry point of the application. It calls cmd.Run to launch
+// and exits with the code of the error it returns (2 when drift is detected).
 func main() {
-	cmd.Run()
+	if err := cmd.Run(os.Args); err != nil {
+		var exitErr cli.ExitCoder
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// DefaultSessionName is the role session name used when an account profile does not set one.
+const DefaultSessionName = "drift-checker"
+
+// AccountProfile is an AWS account scanned by assuming an IAM role in it.
+type AccountProfile struct {
+	RoleARN     string // role to assume, e.g. arn:aws:iam::123456789012:role/drift-checker
+	ExternalID  string // optional, when the role's trust policy requires one
+	SessionName string // shows up in the account's CloudTrail; DefaultSessionName when empty
+}
+
+// AccountID returns the ID of the account the profile's role belongs to.
+func (p AccountProfile) AccountID() (string, error) {
+	parsed, err := arn.Parse(p.RoleARN)
+	if err != nil || parsed.Service != "iam" || parsed.AccountID == "" {
+		return "", common.ErrInvalidRoleARN
+	}
+
+	return parsed.AccountID, nil
+}
+
+// CredentialsFunc returns the credentials used to scan the account of profile, starting from the base config.
+// DefaultAccountCredentials assumes the role through STS; tests can inject their own.
+type CredentialsFunc func(cfg aws.Config, profile AccountProfile) aws.CredentialsProvider
+
+// DefaultAccountCredentials assumes the profile's role through STS, signed with the base config's credentials.
+func DefaultAccountCredentials(cfg aws.Config, profile AccountProfile) aws.CredentialsProvider {
+	return AssumeRoleCredentials(sts.NewFromConfig(cfg), profile)
+}
+
+// AssumeRoleCredentials returns credentials obtained by assuming the profile's role through client.
+// They are cached and refreshed before they expire.
+func AssumeRoleCredentials(client stscreds.AssumeRoleAPIClient, profile AccountProfile) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(client, profile.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = profile.SessionName
+		if o.RoleSessionName == "" {
+			o.RoleSessionName = DefaultSessionName
+		}
+		if profile.ExternalID != "" {
+			o.ExternalID = aws.String(profile.ExternalID)
+		}
+	}))
+}
+
+// NewAccountEC2Services creates one EC2Service per account profile, each using the credentials
+// returned by credentials (DefaultAccountCredentials when nil) for its account.
+func NewAccountEC2Services(ctx context.Context, logger zerolog.Logger, opts ClientOptions, profiles []AccountProfile, credentials CredentialsFunc) ([]EC2Service, error) {
+	log := logger.With().Str(common.LogStrLayer, "aws").Logger()
+
+	// a replayed run reads each account's recording, no role is assumed
+	var cfg aws.Config
+	if opts.ReplayDir == "" {
+		var err error
+		cfg, err = opts.loadConfig(ctx)
+		if err != nil {
+			log.Err(err).Msg("unable to load AWS config")
+			return nil, common.ErrConfigLoadFailure
+		}
+	}
+	if credentials == nil {
+		credentials = DefaultAccountCredentials
+	}
+
+	services := make([]EC2Service, 0, len(profiles))
+	for _, profile := range profiles {
+		accountID, err := profile.AccountID()
+		if err != nil {
+			log.Err(err).Str("role_arn", profile.RoleARN).Msg("invalid account profile")
+			return nil, err
+		}
+		accountLog := log.With().Str("account_id", accountID).Logger()
+
+		var svc *ec2Service
+		if opts.ReplayDir != "" {
+			svc, err = newReplayEC2Service(accountLog, opts, accountID)
+		} else {
+			accountCfg := cfg.Copy()
+			accountCfg.Credentials = credentials(cfg, profile)
+			svc, err = newEC2ServiceFromConfig(accountLog, accountCfg, opts, accountID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, svc)
+	}
+
+	return services, nil
+}
+
+// GetInstancesInAccounts looks the instances up (see GetInstancesInRegions) in every account at the same time.
+// An ID is only reported not found when none of the accounts has it.
+func GetInstancesInAccounts(ctx context.Context, services []EC2Service, instanceIDs map[string][]string) ([]*common.EC2Instance, map[string]error, error) {
+	found := make([][]*common.EC2Instance, len(services))
+	errs := make([]error, len(services))
+
+	var wg sync.WaitGroup
+	for i, svc := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found[i], _, errs[i] = svc.GetInstancesInRegions(ctx, instanceIDs)
+		}()
+	}
+	wg.Wait()
+
+	instances := make([]*common.EC2Instance, 0)
+	seen := make(map[string]bool)
+	for i := range services {
+		if errs[i] != nil {
+			return nil, nil, errs[i]
+		}
+		for _, inst := range found[i] {
+			if !seen[inst.InstanceID] {
+				seen[inst.InstanceID] = true
+				instances = append(instances, inst)
+			}
+		}
+	}
+
+	notFound := make(map[string]error)
+	for _, ids := range instanceIDs {
+		for _, id := range ids {
+			if !seen[id] {
+				notFound[id] = common.ErrInstanceNotFound
+			}
+		}
+	}
+
+	return instances, notFound, nil
+}
+
+// FindInstancesInAccounts retrieves the instances matching the filters (see FindInstancesInRegions)
+// in every account at the same time.
+func FindInstancesInAccounts(ctx context.Context, services []EC2Service, regions []string, filters map[string][]string) ([]*common.EC2Instance, error) {
+	found := make([][]*common.EC2Instance, len(services))
+	errs := make([]error, len(services))
+
+	var wg sync.WaitGroup
+	for i, svc := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found[i], errs[i] = svc.FindInstancesInRegions(ctx, regions, filters)
+		}()
+	}
+	wg.Wait()
+
+	instances := make([]*common.EC2Instance, 0)
+	for i := range services {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		instances = append(instances, found[i]...)
+	}
+
+	return instances, nil
+}
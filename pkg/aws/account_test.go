@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAFAKE</AccessKeyId>
+      <SecretAccessKey>fake-secret</SecretAccessKey>
+      <SessionToken>fake-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/drift-checker/%s</Arn>
+      <AssumedRoleId>AROAFAKE:%s</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata><RequestId>fake</RequestId></ResponseMetadata>
+</AssumeRoleResponse>`
+
+// fakeSTS serves AssumeRole and records the form of every request.
+func fakeSTS(t *testing.T) (*sts.Client, *[]url.Values) {
+	var requests []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		requests = append(requests, r.PostForm)
+		session := r.PostForm.Get("RoleSessionName")
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprintf(w, assumeRoleResponse, session, session)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := sts.New(sts.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIDBASE", "base-secret", ""),
+	})
+
+	return client, &requests
+}
+
+func TestAssumeRoleCredentials(t *testing.T) {
+	client, requests := fakeSTS(t)
+
+	provider := AssumeRoleCredentials(client, AccountProfile{
+		RoleARN:    "arn:aws:iam::123456789012:role/drift-checker",
+		ExternalID: "org-secret",
+	})
+	creds, err := provider.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "ASIAFAKE", creds.AccessKeyID)
+	assert.Equal(t, "fake-token", creds.SessionToken)
+
+	// cached until they expire
+	_, err = provider.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 1)
+
+	form := (*requests)[0]
+	assert.Equal(t, "AssumeRole", form.Get("Action"))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/drift-checker", form.Get("RoleArn"))
+	assert.Equal(t, "org-secret", form.Get("ExternalId"))
+	assert.Equal(t, DefaultSessionName, form.Get("RoleSessionName"))
+}
+
+func TestAccountProfile_AccountID(t *testing.T) {
+	id, err := AccountProfile{RoleARN: "arn:aws:iam::123456789012:role/ops/drift-checker"}.AccountID()
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789012", id)
+
+	for _, roleARN := range []string{"", "drift-checker", "arn:aws:s3:::bucket"} {
+		_, err := AccountProfile{RoleARN: roleARN}.AccountID()
+		assert.ErrorIs(t, err, common.ErrInvalidRoleARN, roleARN)
+	}
+}
+
+func TestNewAccountEC2Services(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	var assumed []AccountProfile
+	fake := func(_ aws.Config, profile AccountProfile) aws.CredentialsProvider {
+		assumed = append(assumed, profile)
+		return credentials.NewStaticCredentialsProvider("AKID-"+profile.RoleARN, "secret", "")
+	}
+
+	profiles := []AccountProfile{
+		{RoleARN: "arn:aws:iam::111111111111:role/drift-checker"},
+		{RoleARN: "arn:aws:iam::222222222222:role/drift-checker", ExternalID: "x"},
+	}
+	services, err := NewAccountEC2Services(context.Background(), zerolog.Nop(), ClientOptions{}, profiles, fake)
+	assert.NoError(t, err)
+	assert.Len(t, services, 2)
+	assert.Equal(t, profiles, assumed)
+
+	creds, err := services[1].(*ec2Service).client.(*retryingClient).next.(*ec2.Client).Options().Credentials.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "AKID-arn:aws:iam::222222222222:role/drift-checker", creds.AccessKeyID)
+
+	_, err = NewAccountEC2Services(context.Background(), zerolog.Nop(), ClientOptions{}, []AccountProfile{{RoleARN: "bad"}}, fake)
+	assert.ErrorIs(t, err, common.ErrInvalidRoleARN)
+}
+
+func TestGetInstancesInAccounts(t *testing.T) {
+	owned := func(owner string, ids ...string) *pagedEC2Client {
+		return &pagedEC2Client{pageSize: 10, instances: liveInstances(ids...), owner: owner}
+	}
+	services := []EC2Service{
+		&ec2Service{client: owned("111111111111", "i-a"), logger: zerolog.Nop()},
+		&ec2Service{client: owned("222222222222", "i-b"), logger: zerolog.Nop()},
+	}
+
+	instances, notFound, err := GetInstancesInAccounts(context.Background(), services, map[string][]string{
+		"": {"i-a", "i-b", "i-missing"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, "111111111111", instances[0].AccountID)
+	assert.Equal(t, "222222222222", instances[1].AccountID)
+	assert.Equal(t, map[string]error{"i-missing": common.ErrInstanceNotFound}, notFound)
+
+	found, err := FindInstancesInAccounts(context.Background(), services, []string{""}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	services = append(services, &ec2Service{
+		client: &pagedEC2Client{mockEC2Client: mockEC2Client{err: assert.AnError}},
+		logger: zerolog.Nop(),
+	})
+	_, _, err = GetInstancesInAccounts(context.Background(), services, map[string][]string{"": {"i-a"}})
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+	_, err = FindInstancesInAccounts(context.Background(), services, []string{""}, nil)
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+}
@@ -3,9 +3,12 @@ package aws
 
 import (
 	"context"
+	"slices"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/rs/zerolog"
 
 	"github.com/odetolakehinde/drift-checker/pkg/common"
 )
@@ -13,20 +16,19 @@ import (
 // EC2Client defines the subset of AWS EC2 methods used by this application.
 type EC2Client interface {
 	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
 }
 
-// GetInstance retrieves the configuration of an EC2 instance by its ID.
-func (s *ec2Service) GetInstance(ctx context.Context, instanceID string) (*common.EC2Instance, error) {
-	log := s.logger.With().Str(common.LogStrMethod, "GetInstance").Logger()
+// describeBatchSize is the number of instance or volume IDs sent in one DescribeInstances or
+// DescribeVolumes request, the most values EC2 accepts in a single filter.
+const describeBatchSize = 200
 
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		log.Err(err).Msg("failed to load AWS config")
-		return nil, common.ErrConfigLoadFailure
-	}
+// describePageSize is the number of instances asked for per DescribeInstances page.
+const describePageSize = 1000
 
-	client := ec2.NewFromConfig(cfg)
-	return s.GetInstanceFromClient(ctx, client, instanceID)
+// GetInstance retrieves the configuration of an EC2 instance by its ID.
+func (s *ec2Service) GetInstance(ctx context.Context, instanceID string) (*common.EC2Instance, error) {
+	return s.GetInstanceFromClient(ctx, s.client, instanceID)
 }
 
 // GetInstanceFromClient retrieves the configuration of a specific EC2 instance
@@ -40,7 +42,7 @@ func (s *ec2Service) GetInstanceFromClient(ctx context.Context, client EC2Client
 	})
 	if err != nil {
 		log.Err(err).Msg("failed to describe instances")
-		return nil, common.ErrAWSDescribeFailure
+		return nil, describeFailure(err, common.ErrAWSDescribeFailure)
 	}
 
 	if len(output.Reservations) == 0 || len(output.Reservations[0].Instances) == 0 {
@@ -48,8 +50,126 @@ func (s *ec2Service) GetInstanceFromClient(ctx context.Context, client EC2Client
 		return nil, common.ErrInstanceNotFound
 	}
 
-	instance := output.Reservations[0].Instances[0]
+	ec2Inst := instanceFromAWS(output.Reservations[0].Instances[0])
+	ec2Inst.AccountID = common.GetString(output.Reservations[0].OwnerId)
+
+	// the volume settings themselves (size, type, ...) are only available from DescribeVolumes
+	fillVolumeSettings(ctx, log, client, ec2Inst)
+
+	return ec2Inst, nil
+}
+
+// GetInstances retrieves the configuration of many EC2 instances with as few API calls as possible.
+func (s *ec2Service) GetInstances(ctx context.Context, instanceIDs []string) ([]*common.EC2Instance, map[string]error, error) {
+	return s.GetInstancesFromClient(ctx, s.client, instanceIDs)
+}
+
+// GetInstancesFromClient retrieves the configuration of the given EC2 instances in batched, paginated
+// DescribeInstances calls. Instances are returned in the order of instanceIDs; IDs that do not exist
+// are reported in the returned map (ErrInstanceNotFound) instead of failing the whole lookup.
+func (s *ec2Service) GetInstancesFromClient(ctx context.Context, client EC2Client, instanceIDs []string) ([]*common.EC2Instance, map[string]error, error) {
+	log := s.logger.With().Str(common.LogStrMethod, "GetInstancesFromClient").Logger()
+
+	ids := make([]string, 0, len(instanceIDs))
+	seen := make(map[string]bool, len(instanceIDs))
+	for _, id := range instanceIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	found := make(map[string]*common.EC2Instance, len(ids))
+	for batch := range slices.Chunk(ids, describeBatchSize) {
+		// an instance-id filter rather than InstanceIds: with InstanceIds a single unknown ID
+		// fails the whole request, with a filter it is simply left out of the response
+		described, err := describeInstances(ctx, client, []ec2Types.Filter{{Name: aws.String("instance-id"), Values: batch}})
+		if err != nil {
+			log.Err(err).Msg("failed to describe instances")
+			return nil, nil, describeFailure(err, common.ErrAWSDescribeFailure)
+		}
+		for _, ec2Inst := range described {
+			found[ec2Inst.InstanceID] = ec2Inst
+		}
+	}
+
+	instances := make([]*common.EC2Instance, 0, len(found))
+	notFound := make(map[string]error)
+	for _, id := range ids {
+		if inst, ok := found[id]; ok {
+			instances = append(instances, inst)
+			continue
+		}
+		log.Warn().Str("instance_id", id).Msg("instance not found")
+		notFound[id] = common.ErrInstanceNotFound
+	}
+
+	fillVolumeSettings(ctx, log, client, instances...)
+
+	return instances, notFound, nil
+}
+
+// FindInstances retrieves the configuration of every EC2 instance matching the given DescribeInstances filters.
+func (s *ec2Service) FindInstances(ctx context.Context, filters map[string][]string) ([]*common.EC2Instance, error) {
+	return s.FindInstancesFromClient(ctx, s.client, filters)
+}
+
+// FindInstancesFromClient retrieves the configuration of every EC2 instance matching the given
+// DescribeInstances filters (e.g. "tag:Env": {"prod"}, "instance-state-name": {"running"}).
+// Without filters, every instance of the region is returned.
+func (s *ec2Service) FindInstancesFromClient(ctx context.Context, client EC2Client, filters map[string][]string) ([]*common.EC2Instance, error) {
+	log := s.logger.With().Str(common.LogStrMethod, "FindInstancesFromClient").Logger()
+
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	ec2Filters := make([]ec2Types.Filter, 0, len(names))
+	for _, name := range names {
+		ec2Filters = append(ec2Filters, ec2Types.Filter{Name: aws.String(name), Values: filters[name]})
+	}
+
+	instances, err := describeInstances(ctx, client, ec2Filters)
+	if err != nil {
+		log.Err(err).Msg("failed to describe instances")
+		return nil, describeFailure(err, common.ErrAWSDescribeFailure)
+	}
+
+	fillVolumeSettings(ctx, log, client, instances...)
+
+	return instances, nil
+}
+
+// describeInstances pages through DescribeInstances for the given filters.
+func describeInstances(ctx context.Context, client EC2Client, filters []ec2Types.Filter) ([]*common.EC2Instance, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{
+		Filters:    filters,
+		MaxResults: aws.Int32(describePageSize),
+	})
+
+	var instances []*common.EC2Instance
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				ec2Inst := instanceFromAWS(instance)
+				ec2Inst.AccountID = common.GetString(reservation.OwnerId)
+				instances = append(instances, ec2Inst)
+			}
+		}
+	}
+
+	return instances, nil
+}
 
+// instanceFromAWS maps a described EC2 instance onto an EC2Instance. The EBS volume settings are
+// filled in separately by describeVolumes.
+func instanceFromAWS(instance ec2Types.Instance) *common.EC2Instance {
 	// extract security groups (using GroupId as an identifier)
 	var sgIDs []string
 	for _, sg := range instance.SecurityGroups {
@@ -70,13 +190,16 @@ func (s *ec2Service) GetInstanceFromClient(ctx context.Context, client EC2Client
 	var bdms []common.BlockDeviceMapping
 	for _, mapping := range instance.BlockDeviceMappings {
 		var volumeID string
-		if mapping.Ebs != nil && mapping.Ebs.VolumeId != nil {
-			volumeID = *mapping.Ebs.VolumeId
+		var deleteOnTermination bool
+		if mapping.Ebs != nil {
+			volumeID = common.GetString(mapping.Ebs.VolumeId)
+			deleteOnTermination = getBool(mapping.Ebs.DeleteOnTermination)
 		}
 		if mapping.DeviceName != nil {
 			bdms = append(bdms, common.BlockDeviceMapping{
-				DeviceName: *mapping.DeviceName,
-				VolumeID:   volumeID,
+				DeviceName:          *mapping.DeviceName,
+				VolumeID:            volumeID,
+				DeleteOnTermination: deleteOnTermination,
 			})
 		}
 	}
@@ -99,12 +222,17 @@ func (s *ec2Service) GetInstanceFromClient(ctx context.Context, client EC2Client
 		availabilityZone = *instance.Placement.AvailabilityZone
 	}
 
-	ec2Inst := &common.EC2Instance{
+	var state string
+	if instance.State != nil {
+		state = string(instance.State.Name)
+	}
+
+	return &common.EC2Instance{
 		InstanceID:          common.GetString(instance.InstanceId),
 		InstanceType:        string(instance.InstanceType),
 		ImageID:             common.GetString(instance.ImageId),
 		KeyName:             common.GetString(instance.KeyName),
-		State:               string(instance.State.Name),
+		State:               state,
 		AvailabilityZone:    availabilityZone,
 		PrivateIPAddress:    common.GetString(instance.PrivateIpAddress),
 		PublicIPAddress:     common.GetString(instance.PublicIpAddress),
@@ -117,7 +245,84 @@ func (s *ec2Service) GetInstanceFromClient(ctx context.Context, client EC2Client
 		Monitoring:          monitoringEnabled,
 		Architecture:        string(instance.Architecture),
 		VirtualizationType:  string(instance.VirtualizationType),
+		LaunchTime:          aws.ToTime(instance.LaunchTime),
 	}
+}
 
-	return ec2Inst, nil
+// fillVolumeSettings reads the EBS settings of the instances' volumes. DescribeVolumes failing, e.g. for a policy
+// that only allows ec2:DescribeInstances, does not fail the lookup: the settings are left unknown and not compared.
+func fillVolumeSettings(ctx context.Context, log zerolog.Logger, client EC2Client, instances ...*common.EC2Instance) {
+	if err := describeVolumes(ctx, client, instances...); err != nil {
+		log.Warn().Err(err).Msg("failed to describe volumes, EBS volume settings are not compared")
+		for _, inst := range instances {
+			inst.VolumeSettingsUnknown = true
+		}
+	}
+}
+
+// describeVolumes fills in the EBS settings of the instances' block device mappings from DescribeVolumes,
+// describing the volumes of all the instances together.
+//
+// Volumes are selected with a volume-id filter rather than VolumeIds: a volume deleted since DescribeInstances
+// is then simply left out, where VolumeIds fails the whole request with InvalidVolume.NotFound.
+func describeVolumes(ctx context.Context, client EC2Client, instances ...*common.EC2Instance) error {
+	// a multi-attach volume shows up on every instance it is attached to
+	byVolume := make(map[string][]*common.BlockDeviceMapping)
+	var volumeIDs []string
+	for _, inst := range instances {
+		bdms := inst.BlockDeviceMappings
+		for i := range bdms {
+			id := bdms[i].VolumeID
+			if id == "" {
+				continue
+			}
+			if _, ok := byVolume[id]; !ok {
+				volumeIDs = append(volumeIDs, id)
+			}
+			byVolume[id] = append(byVolume[id], &bdms[i])
+		}
+	}
+
+	for batch := range slices.Chunk(volumeIDs, describeBatchSize) {
+		input := &ec2.DescribeVolumesInput{
+			Filters: []ec2Types.Filter{{Name: aws.String("volume-id"), Values: batch}},
+		}
+		for {
+			output, err := client.DescribeVolumes(ctx, input)
+			if err != nil {
+				return err
+			}
+
+			for _, volume := range output.Volumes {
+				for _, bdm := range byVolume[common.GetString(volume.VolumeId)] {
+					bdm.VolumeSize = int(getInt32(volume.Size))
+					bdm.VolumeType = string(volume.VolumeType)
+					bdm.Iops = int(getInt32(volume.Iops))
+					bdm.Throughput = int(getInt32(volume.Throughput))
+					bdm.Encrypted = getBool(volume.Encrypted)
+					bdm.KmsKeyID = common.GetString(volume.KmsKeyId)
+				}
+			}
+
+			if common.GetString(output.NextToken) == "" {
+				break
+			}
+			input.NextToken = output.NextToken
+		}
+	}
+
+	return nil
+}
+
+// getBool safely dereferences an AWS bool pointer.
+func getBool(b *bool) bool {
+	return b != nil && *b
+}
+
+// getInt32 safely dereferences an AWS int32 pointer.
+func getInt32(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
 }
@@ -2,8 +2,14 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/rs/zerolog"
@@ -16,12 +22,34 @@ import (
 type mockEC2Client struct {
 	output *ec2.DescribeInstancesOutput
 	err    error
+
+	volumes      []ec2Types.Volume
+	volumesErr   error
+	volumeInputs []*ec2.DescribeVolumesInput
 }
 
 func (m *mockEC2Client) DescribeInstances(_ context.Context, _ *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
 	return m.output, m.err
 }
 
+// DescribeVolumes serves the volumes matching the volume-id filter, leaving out the ones that do not exist like EC2 does.
+func (m *mockEC2Client) DescribeVolumes(_ context.Context, params *ec2.DescribeVolumesInput, _ ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	m.volumeInputs = append(m.volumeInputs, params)
+	if m.volumesErr != nil {
+		return nil, m.volumesErr
+	}
+
+	var volumes []ec2Types.Volume
+	for _, volume := range m.volumes {
+		for _, f := range params.Filters {
+			if aws.ToString(f.Name) == "volume-id" && slices.Contains(f.Values, aws.ToString(volume.VolumeId)) {
+				volumes = append(volumes, volume)
+			}
+		}
+	}
+	return &ec2.DescribeVolumesOutput{Volumes: volumes}, nil
+}
+
 func TestGetInstanceFromClient_Success(t *testing.T) {
 	client := &mockEC2Client{
 		output: &ec2.DescribeInstancesOutput{
@@ -100,3 +128,244 @@ func TestGetInstanceFromClient_DescribeError(t *testing.T) {
 	_, err := svc.GetInstanceFromClient(context.Background(), client, "i-err")
 	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
 }
+
+func TestGetInstanceFromClient_VolumeSettings(t *testing.T) {
+	client := &mockEC2Client{
+		output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2Types.Reservation{{
+				Instances: []ec2Types.Instance{{
+					InstanceId: common.GetStringPointer("i-1"),
+					State:      &ec2Types.InstanceState{Name: "running"},
+					BlockDeviceMappings: []ec2Types.InstanceBlockDeviceMapping{{
+						DeviceName: common.GetStringPointer("/dev/xvda"),
+						Ebs: &ec2Types.EbsInstanceBlockDevice{
+							VolumeId:            common.GetStringPointer("vol-1"),
+							DeleteOnTermination: aws.Bool(true),
+						},
+					}},
+				}},
+			}},
+		},
+		volumes: []ec2Types.Volume{{
+			VolumeId:   common.GetStringPointer("vol-1"),
+			Size:       aws.Int32(50),
+			VolumeType: "gp3",
+			Iops:       aws.Int32(3000),
+			Throughput: aws.Int32(125),
+			Encrypted:  aws.Bool(true),
+			KmsKeyId:   common.GetStringPointer("arn:aws:kms:us-east-1:123456789012:key/abc"),
+		}},
+	}
+	svc := &ec2Service{logger: zerolog.Nop()}
+
+	result, err := svc.GetInstanceFromClient(context.Background(), client, "i-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []common.BlockDeviceMapping{{
+		DeviceName:          "/dev/xvda",
+		VolumeID:            "vol-1",
+		VolumeSize:          50,
+		VolumeType:          "gp3",
+		Iops:                3000,
+		Throughput:          125,
+		Encrypted:           true,
+		KmsKeyID:            "arn:aws:kms:us-east-1:123456789012:key/abc",
+		DeleteOnTermination: true,
+	}}, result.BlockDeviceMappings)
+
+	assert.False(t, result.VolumeSettingsUnknown)
+
+	// without ec2:DescribeVolumes the instance is still returned, its volume settings unknown
+	client.volumesErr = assert.AnError
+	result, err = svc.GetInstanceFromClient(context.Background(), client, "i-1")
+	assert.NoError(t, err)
+	assert.True(t, result.VolumeSettingsUnknown)
+	assert.Equal(t, 0, result.BlockDeviceMappings[0].VolumeSize)
+	assert.True(t, result.BlockDeviceMappings[0].DeleteOnTermination)
+}
+
+// pagedEC2Client serves instances like EC2 does for DescribeInstances filters: instances that do not
+// match are left out and results come back pageSize at a time.
+type pagedEC2Client struct {
+	mockEC2Client
+
+	instances []ec2Types.Instance
+	owner     string // account ID reported as the reservations' owner
+	pageSize  int
+	calls     []*ec2.DescribeInstancesInput
+}
+
+func (m *pagedEC2Client) DescribeInstances(_ context.Context, params *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	m.calls = append(m.calls, params)
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var matched []ec2Types.Instance
+	for _, inst := range m.instances {
+		if matchesFilters(inst, params.Filters) {
+			matched = append(matched, inst)
+		}
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		start, _ = strconv.Atoi(*params.NextToken)
+	}
+	end := min(start+m.pageSize, len(matched))
+
+	output := &ec2.DescribeInstancesOutput{
+		Reservations: []ec2Types.Reservation{{Instances: matched[start:end], OwnerId: aws.String(m.owner)}},
+	}
+	if end < len(matched) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return output, nil
+}
+
+// matchesFilters supports the instance-id, instance-state-name and tag:<key> filters.
+func matchesFilters(inst ec2Types.Instance, filters []ec2Types.Filter) bool {
+	for _, filter := range filters {
+		var value string
+		switch name := *filter.Name; {
+		case name == "instance-id":
+			value = *inst.InstanceId
+		case name == "instance-state-name":
+			value = string(inst.State.Name)
+		case strings.HasPrefix(name, "tag:"):
+			for _, tag := range inst.Tags {
+				if *tag.Key == strings.TrimPrefix(name, "tag:") {
+					value = *tag.Value
+				}
+			}
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetInstancesFromClient_BatchesAndPaginates(t *testing.T) {
+	client := &pagedEC2Client{pageSize: 150}
+	var ids []string
+	for i := range 250 {
+		id := fmt.Sprintf("i-%04d", i)
+		ids = append(ids, id)
+		client.instances = append(client.instances, ec2Types.Instance{
+			InstanceId: common.GetStringPointer(id),
+			State:      &ec2Types.InstanceState{Name: "running"},
+		})
+	}
+	svc := &ec2Service{logger: zerolog.Nop()}
+
+	instances, notFound, err := svc.GetInstancesFromClient(context.Background(), client, append(ids, "i-missing", "i-0001"))
+	assert.NoError(t, err)
+	assert.Len(t, instances, 250)
+	assert.Equal(t, "i-0000", instances[0].InstanceID)
+	assert.Equal(t, "i-0249", instances[249].InstanceID)
+	assert.Equal(t, map[string]error{"i-missing": common.ErrInstanceNotFound}, notFound)
+
+	// 200 IDs (two pages) + 51 IDs (one page)
+	assert.Len(t, client.calls, 3)
+	assert.Len(t, client.calls[0].Filters[0].Values, 200)
+	assert.Equal(t, "150", *client.calls[1].NextToken)
+	assert.Len(t, client.calls[2].Filters[0].Values, 51)
+}
+
+func TestGetInstancesFromClient_DescribeError(t *testing.T) {
+	client := &pagedEC2Client{mockEC2Client: mockEC2Client{err: assert.AnError}, pageSize: 10}
+	svc := &ec2Service{logger: zerolog.Nop()}
+
+	_, _, err := svc.GetInstancesFromClient(context.Background(), client, []string{"i-1"})
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+}
+
+func TestGetInstancesFromClient_VolumeSettings(t *testing.T) {
+	client := &pagedEC2Client{
+		pageSize: 10,
+		instances: []ec2Types.Instance{{
+			InstanceId: common.GetStringPointer("i-1"),
+			BlockDeviceMappings: []ec2Types.InstanceBlockDeviceMapping{{
+				DeviceName: common.GetStringPointer("/dev/xvda"),
+				Ebs:        &ec2Types.EbsInstanceBlockDevice{VolumeId: common.GetStringPointer("vol-1")},
+			}},
+		}},
+		mockEC2Client: mockEC2Client{volumes: []ec2Types.Volume{{
+			VolumeId: common.GetStringPointer("vol-1"),
+			Size:     aws.Int32(20),
+		}}},
+	}
+	svc := &ec2Service{logger: zerolog.Nop()}
+
+	instances, notFound, err := svc.GetInstancesFromClient(context.Background(), client, []string{"i-1"})
+	assert.NoError(t, err)
+	assert.Empty(t, notFound)
+	assert.Equal(t, 20, instances[0].BlockDeviceMappings[0].VolumeSize)
+
+	client.volumesErr = assert.AnError
+	instances, _, err = svc.GetInstancesFromClient(context.Background(), client, []string{"i-1"})
+	assert.NoError(t, err)
+	assert.True(t, instances[0].VolumeSettingsUnknown)
+}
+
+func TestDescribeVolumes(t *testing.T) {
+	device := func(name, volumeID string) common.BlockDeviceMapping {
+		return common.BlockDeviceMapping{DeviceName: name, VolumeID: volumeID}
+	}
+	// vol-shared is multi-attached to both instances, vol-deleted is gone since DescribeInstances
+	a := &common.EC2Instance{InstanceID: "i-a", BlockDeviceMappings: []common.BlockDeviceMapping{device("/dev/xvda", "vol-a"), device("/dev/sdf", "vol-shared")}}
+	b := &common.EC2Instance{InstanceID: "i-b", BlockDeviceMappings: []common.BlockDeviceMapping{device("/dev/sdg", "vol-shared"), device("/dev/sdh", "vol-deleted")}}
+	client := &mockEC2Client{volumes: []ec2Types.Volume{
+		{VolumeId: common.GetStringPointer("vol-a"), Size: aws.Int32(8)},
+		{VolumeId: common.GetStringPointer("vol-shared"), Size: aws.Int32(100), VolumeType: "io2"},
+	}}
+
+	assert.NoError(t, describeVolumes(context.Background(), client, a, b))
+	assert.Len(t, client.volumeInputs, 1)
+	assert.Empty(t, client.volumeInputs[0].VolumeIds)
+	assert.Equal(t, []string{"vol-a", "vol-shared", "vol-deleted"}, client.volumeInputs[0].Filters[0].Values)
+
+	assert.Equal(t, 8, a.BlockDeviceMappings[0].VolumeSize)
+	assert.Equal(t, 100, a.BlockDeviceMappings[1].VolumeSize)
+	assert.Equal(t, 100, b.BlockDeviceMappings[0].VolumeSize)
+	assert.Equal(t, "io2", b.BlockDeviceMappings[0].VolumeType)
+	assert.Equal(t, 0, b.BlockDeviceMappings[1].VolumeSize)
+}
+
+func TestFindInstancesFromClient(t *testing.T) {
+	tagged := func(id, env string, state ec2Types.InstanceStateName) ec2Types.Instance {
+		return ec2Types.Instance{
+			InstanceId: common.GetStringPointer(id),
+			State:      &ec2Types.InstanceState{Name: state},
+			Tags:       []ec2Types.Tag{{Key: common.GetStringPointer("Env"), Value: common.GetStringPointer(env)}},
+		}
+	}
+	client := &pagedEC2Client{
+		pageSize: 1,
+		instances: []ec2Types.Instance{
+			tagged("i-1", "prod", "running"),
+			tagged("i-2", "dev", "running"),
+			tagged("i-3", "prod", "stopped"),
+			tagged("i-4", "prod", "running"),
+		},
+	}
+	launched := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	client.instances[0].LaunchTime = &launched
+	svc := &ec2Service{logger: zerolog.Nop()}
+
+	instances, err := svc.FindInstancesFromClient(context.Background(), client, map[string][]string{
+		"tag:Env":             {"prod"},
+		"instance-state-name": {"running"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, "i-1", instances[0].InstanceID)
+	assert.Equal(t, "i-4", instances[1].InstanceID)
+	assert.Equal(t, launched, instances[0].LaunchTime)
+	assert.Len(t, client.calls, 2)
+	assert.Equal(t, "instance-state-name", *client.calls[0].Filters[0].Name)
+
+	client.err = assert.AnError
+	_, err = svc.FindInstancesFromClient(context.Background(), client, nil)
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+}
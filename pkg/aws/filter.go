@@ -0,0 +1,26 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// ParseInstanceFilters turns name=value[,value...] expressions such as "tag:Env=prod" or
+// "instance-state-name=running,stopped" into DescribeInstances filters. Values given for the
+// same name are merged, so EC2 matches any of them.
+func ParseInstanceFilters(expressions []string) (map[string][]string, error) {
+	filters := make(map[string][]string, len(expressions))
+	for _, expr := range expressions {
+		name, raw, ok := strings.Cut(expr, "=")
+		name = strings.TrimSpace(name)
+		values := common.ParseCommaList(raw)
+		if !ok || name == "" || len(values) == 0 {
+			return nil, common.ErrInvalidInstanceFilter
+		}
+
+		filters[name] = append(filters[name], values...)
+	}
+
+	return filters, nil
+}
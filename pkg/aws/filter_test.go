@@ -0,0 +1,29 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+func TestParseInstanceFilters(t *testing.T) {
+	filters, err := ParseInstanceFilters([]string{
+		"tag:Env=prod",
+		"vpc-id=vpc-123",
+		"instance-state-name=running, stopped",
+		"tag:Env=staging",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"tag:Env":             {"prod", "staging"},
+		"vpc-id":              {"vpc-123"},
+		"instance-state-name": {"running", "stopped"},
+	}, filters)
+
+	for _, expr := range []string{"tag:Env", "=prod", "vpc-id="} {
+		_, err := ParseInstanceFilters([]string{expr})
+		assert.ErrorIs(t, err, common.ErrInvalidInstanceFilter, expr)
+	}
+}
@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ClientOptions configures how the AWS clients are built. The zero value uses the SDK's default
+// configuration (environment, shared config files, instance role, ...).
+type ClientOptions struct {
+	Profile     string  // shared config profile to use instead of AWS_PROFILE / "default"
+	Region      string  // default region, overriding AWS_REGION and the profile's region
+	EndpointURL string  // custom endpoint for every AWS service, e.g. http://localhost:4566 for LocalStack
+	MaxRetries  *int    // retries after the first attempt, 0 disables them; nil keeps the default (DefaultMaxRetries for EC2)
+	RateLimit   float64 // EC2 requests per second and region; 0 uses DefaultRateLimit
+	RecordDir   string  // when set, every EC2 response is saved under this directory
+	ReplayDir   string  // when set, EC2 responses are read from this recording instead of AWS
+}
+
+// loadConfig loads the AWS configuration with the options applied on top of the defaults.
+func (o ClientOptions) loadConfig(ctx context.Context) (aws.Config, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if o.Profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(o.Profile))
+	}
+	if o.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(o.Region))
+	}
+	if o.EndpointURL != "" {
+		loadOpts = append(loadOpts, config.WithBaseEndpoint(o.EndpointURL))
+	}
+	if o.MaxRetries != nil {
+		loadOpts = append(loadOpts, config.WithRetryMaxAttempts(o.ec2MaxRetries()+1))
+	}
+
+	return config.LoadDefaultConfig(ctx, loadOpts...)
+}
+
+// ec2MaxRetries returns the number of retries of an EC2 request: MaxRetries when set, else DefaultMaxRetries.
+func (o ClientOptions) ec2MaxRetries() int {
+	if o.MaxRetries == nil {
+		return DefaultMaxRetries
+	}
+	return max(*o.MaxRetries, 0)
+}
@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptions_LoadConfig(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	cfg, err := ClientOptions{}.loadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.Nil(t, cfg.BaseEndpoint)
+	assert.Zero(t, cfg.RetryMaxAttempts)
+
+	maxRetries := 2
+	cfg, err = ClientOptions{Region: "eu-west-1", EndpointURL: "http://localhost:4566", MaxRetries: &maxRetries}.loadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, "http://localhost:4566", *cfg.BaseEndpoint)
+	assert.Equal(t, 3, cfg.RetryMaxAttempts)
+
+	// 0 is a single attempt, not the default
+	noRetries := 0
+	opts := ClientOptions{MaxRetries: &noRetries}
+	cfg, err = opts.loadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cfg.RetryMaxAttempts)
+	assert.Equal(t, 0, opts.ec2MaxRetries())
+	assert.Equal(t, DefaultMaxRetries, ClientOptions{}.ec2MaxRetries())
+}
+
+func TestClientOptions_Profile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	assert.NoError(t, os.WriteFile(configFile, []byte("[profile ci]\nregion = ap-south-1\naws_access_key_id = fake\naws_secret_access_key = fake\n"), 0o600))
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_REGION", "")
+
+	cfg, err := ClientOptions{Profile: "ci"}.loadConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "ap-south-1", cfg.Region)
+
+	_, err = ClientOptions{Profile: "missing"}.loadConfig(context.Background())
+	assert.Error(t, err)
+}
+
+func TestNewEC2Service_Options(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+
+	svc, err := NewEC2Service(context.Background(), zerolog.Nop(), ClientOptions{Region: "eu-west-1", EndpointURL: "http://localhost:4566"})
+	assert.NoError(t, err)
+
+	options := svc.(*ec2Service).client.(*retryingClient).next.(*ec2.Client).Options()
+	assert.Equal(t, "eu-west-1", options.Region)
+	assert.Equal(t, "http://localhost:4566", *options.BaseEndpoint)
+
+	// clients of other regions keep the endpoint
+	options = svc.(*ec2Service).clientFor("us-west-2").(*retryingClient).next.(*ec2.Client).Options()
+	assert.Equal(t, "us-west-2", options.Region)
+	assert.Equal(t, "http://localhost:4566", *options.BaseEndpoint)
+}
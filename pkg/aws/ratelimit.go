@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRateLimit is the default number of EC2 requests per second sent to a region. It matches
+	// the refill rate of EC2's own token bucket for Describe* calls.
+	DefaultRateLimit = 20.0
+
+	// minRateLimit is the slowest rate the limiter backs off to while AWS keeps throttling.
+	minRateLimit = 0.5
+)
+
+// tokenBucket is a client-side rate limiter. Every request takes a token; tokens refill at rate per second
+// up to burst. The rate adapts: it is halved whenever AWS throttles a request and climbs back towards
+// maxRate as requests succeed.
+type tokenBucket struct {
+	mu      sync.Mutex
+	rate    float64
+	maxRate float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newTokenBucket creates a full bucket refilling at rate tokens per second (DefaultRateLimit when not positive).
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		rate = DefaultRateLimit
+	}
+	burst := max(rate, 1)
+
+	return &tokenBucket{
+		rate:    rate,
+		maxRate: rate,
+		burst:   burst,
+		tokens:  burst,
+		last:    time.Now(),
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := b.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// Throttled halves the rate and empties the bucket, so requests slow down right away.
+func (b *tokenBucket) Throttled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.rate = max(b.rate/2, minRateLimit)
+	b.tokens = min(b.tokens, 0)
+}
+
+// Succeeded raises the rate again by a twentieth of the configured rate, up to the configured rate.
+func (b *tokenBucket) Succeeded() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.rate = min(b.rate+b.maxRate/20, b.maxRate)
+}
+
+// refill adds the tokens earned since the last refill. The caller holds b.mu.
+func (b *tokenBucket) refill() {
+	now := b.now()
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.burst)
+	b.last = now
+}
+
+// sleepContext waits for d, or returns early with the context's error.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock drives a tokenBucket without sleeping: sleeping advances the clock.
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func newFakeBucket(rate float64) (*tokenBucket, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	bucket := newTokenBucket(rate)
+	bucket.last = clock.now
+	bucket.now = func() time.Time { return clock.now }
+	bucket.sleep = func(_ context.Context, d time.Duration) error {
+		clock.slept = append(clock.slept, d)
+		clock.now = clock.now.Add(d)
+		return nil
+	}
+
+	return bucket, clock
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	bucket, clock := newFakeBucket(2)
+
+	// the burst goes through at once, then one request every 1/rate
+	for range 4 {
+		assert.NoError(t, bucket.Wait(context.Background()))
+	}
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, clock.slept)
+}
+
+func TestTokenBucket_Adapts(t *testing.T) {
+	bucket, clock := newFakeBucket(4)
+
+	bucket.Throttled()
+	assert.Equal(t, 2.0, bucket.rate)
+	assert.NoError(t, bucket.Wait(context.Background()))
+	assert.Equal(t, []time.Duration{500 * time.Millisecond}, clock.slept)
+
+	for range 10 {
+		bucket.Throttled()
+	}
+	assert.Equal(t, minRateLimit, bucket.rate)
+
+	for range 100 {
+		bucket.Succeeded()
+	}
+	assert.Equal(t, 4.0, bucket.rate)
+}
+
+func TestTokenBucket_Cancelled(t *testing.T) {
+	bucket := newTokenBucket(1)
+	assert.NoError(t, bucket.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, bucket.Wait(ctx), context.Canceled)
+}
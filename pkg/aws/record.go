@@ -0,0 +1,176 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// DefaultAccount names the recording directory of the account of the default credentials,
+// next to the directories of the accounts assumed into (named by account ID).
+const DefaultAccount = "default"
+
+// recordingManifest is the file of an account's recording that remembers its default region.
+const recordingManifest = "recording.json"
+
+type (
+	// recordedCall is one EC2 response saved by recordingClient, together with the request that got it.
+	recordedCall struct {
+		Operation string          `json:"operation"`
+		Input     json.RawMessage `json:"input"`
+		Output    json.RawMessage `json:"output"`
+	}
+
+	// manifest describes an account's recording.
+	manifest struct {
+		Region string `json:"region"`
+	}
+)
+
+// recordingClient passes requests on to next and saves every successful response under dir,
+// one file per distinct request, so replayClient can serve them later.
+type recordingClient struct {
+	next EC2Client
+	dir  string
+}
+
+// DescribeInstances calls next and records the response.
+func (c *recordingClient) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	output, err := c.next.DescribeInstances(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, record(c.dir, "DescribeInstances", params, output)
+}
+
+// DescribeVolumes calls next and records the response.
+func (c *recordingClient) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	output, err := c.next.DescribeVolumes(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, record(c.dir, "DescribeVolumes", params, output)
+}
+
+// replayClient serves the responses saved by recordingClient under dir instead of calling AWS.
+// A request that was never recorded fails with common.ErrResponseNotRecorded.
+type replayClient struct {
+	dir string
+}
+
+// DescribeInstances returns the recorded response to params.
+func (c *replayClient) DescribeInstances(_ context.Context, params *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	output := &ec2.DescribeInstancesOutput{}
+	if err := replay(c.dir, "DescribeInstances", params, output); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// DescribeVolumes returns the recorded response to params.
+func (c *replayClient) DescribeVolumes(_ context.Context, params *ec2.DescribeVolumesInput, _ ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	output := &ec2.DescribeVolumesOutput{}
+	if err := replay(c.dir, "DescribeVolumes", params, output); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// recordingPath returns the file holding the response of operation to input: the same request always maps to
+// the same file, so a replayed run reads exactly what the recorded run got.
+func recordingPath(dir, operation string, input any) (string, json.RawMessage, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(raw)
+
+	return filepath.Join(dir, fmt.Sprintf("%s_%s.json", operation, hex.EncodeToString(sum[:8]))), raw, nil
+}
+
+// record saves output as the response of operation to input.
+func record(dir, operation string, input, output any) error {
+	path, rawInput, err := recordingPath(dir, operation, input)
+	if err != nil {
+		return fmt.Errorf("%w: %w", common.ErrRecordFailure, err)
+	}
+	rawOutput, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("%w: %w", common.ErrRecordFailure, err)
+	}
+
+	if err := writeJSON(path, recordedCall{Operation: operation, Input: rawInput, Output: rawOutput}); err != nil {
+		return fmt.Errorf("%w: %w", common.ErrRecordFailure, err)
+	}
+
+	return nil
+}
+
+// replay reads the recorded response of operation to input into output.
+func replay(dir, operation string, input, output any) error {
+	path, _, err := recordingPath(dir, operation, input)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s %s", common.ErrResponseNotRecorded, operation, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	var call recordedCall
+	if err := json.Unmarshal(data, &call); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return json.Unmarshal(call.Output, output)
+}
+
+// writeManifest remembers the default region of the account recorded under dir.
+func writeManifest(dir, region string) error {
+	return writeJSON(filepath.Join(dir, recordingManifest), manifest{Region: region})
+}
+
+// readManifest returns the default region of the account recorded under dir.
+func readManifest(dir string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(filepath.Join(dir, recordingManifest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, fmt.Errorf("%w: %s", common.ErrRecordingNotFound, dir)
+	}
+	if err != nil {
+		return m, err
+	}
+
+	return m, json.Unmarshal(data, &m)
+}
+
+// writeJSON writes v as indented JSON to path, creating its directory.
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
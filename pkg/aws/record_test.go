@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	launched := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	live := liveInstances("i-a", "i-b", "i-c")
+	live[0].LaunchTime = aws.Time(launched)
+	live[0].BlockDeviceMappings = []ec2Types.InstanceBlockDeviceMapping{{
+		DeviceName: aws.String("/dev/xvda"),
+		Ebs:        &ec2Types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-1")},
+	}}
+	source := &pagedEC2Client{
+		mockEC2Client: mockEC2Client{volumes: []ec2Types.Volume{{VolumeId: aws.String("vol-1"), Size: aws.Int32(30)}}},
+		instances:     live,
+		owner:         "111111111111",
+		pageSize:      2,
+	}
+
+	// record a run against the source, with its default region
+	recordDir := filepath.Join(dir, DefaultAccount)
+	assert.NoError(t, writeManifest(recordDir, "us-east-1"))
+	recorded := &ec2Service{client: &recordingClient{next: source, dir: filepath.Join(recordDir, "us-east-1")}, logger: zerolog.Nop()}
+	want, notFound, err := recorded.GetInstances(context.Background(), []string{"i-a", "i-c", "i-missing"})
+	assert.NoError(t, err)
+	assert.Len(t, want, 2)
+
+	// replay it without the source
+	svc, err := NewEC2Service(context.Background(), zerolog.Nop(), ClientOptions{ReplayDir: dir})
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", svc.(*ec2Service).region)
+
+	got, gotNotFound, err := svc.GetInstances(context.Background(), []string{"i-a", "i-c", "i-missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, notFound, gotNotFound)
+	assert.Equal(t, "111111111111", got[0].AccountID)
+	assert.Equal(t, launched, got[0].LaunchTime)
+	assert.Equal(t, 30, got[0].BlockDeviceMappings[0].VolumeSize)
+
+	// a request the recorded run did not make
+	_, _, err = svc.GetInstances(context.Background(), []string{"i-b"})
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+	_, err = (&replayClient{dir: filepath.Join(recordDir, "us-east-1")}).DescribeInstances(context.Background(), nil)
+	assert.ErrorIs(t, err, common.ErrResponseNotRecorded)
+
+	// other regions are read from their own directory
+	_, _, err = svc.GetInstancesInRegions(context.Background(), map[string][]string{"eu-west-1": {"i-a"}})
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+}
+
+func TestNewEC2Service_Record(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+	dir := t.TempDir()
+
+	svc, err := NewEC2Service(context.Background(), zerolog.Nop(), ClientOptions{Region: "eu-west-1", RecordDir: dir})
+	assert.NoError(t, err)
+
+	client := svc.(*ec2Service).clientFor("us-west-2").(*retryingClient).next.(*recordingClient)
+	assert.Equal(t, filepath.Join(dir, DefaultAccount, "us-west-2"), client.dir)
+
+	recorded, err := readManifest(filepath.Join(dir, DefaultAccount))
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", recorded.Region)
+}
+
+func TestNewEC2Service_Replay(t *testing.T) {
+	// no AWS configuration is loaded: a broken one does not matter
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("AWS_PROFILE", "does-not-exist")
+	dir := t.TempDir()
+
+	_, err := NewEC2Service(context.Background(), zerolog.Nop(), ClientOptions{ReplayDir: dir})
+	assert.ErrorIs(t, err, common.ErrRecordingNotFound)
+
+	assert.NoError(t, writeManifest(filepath.Join(dir, "222222222222"), "us-east-1"))
+	services, err := NewAccountEC2Services(context.Background(), zerolog.Nop(), ClientOptions{ReplayDir: dir, Region: "eu-west-1"},
+		[]AccountProfile{{RoleARN: "arn:aws:iam::222222222222:role/drift-checker"}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, services, 1)
+	assert.Equal(t, "eu-west-1", services[0].(*ec2Service).region)
+	assert.Equal(t, &replayClient{dir: filepath.Join(dir, "222222222222", "eu-west-1")}, services[0].(*ec2Service).client)
+}
@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// GetInstancesInRegions retrieves the configuration of EC2 instances grouped by region (see RegionsForInstances),
+// describing every region at the same time. An ID listed under several regions is only reported
+// not found when none of them has it.
+func (s *ec2Service) GetInstancesInRegions(ctx context.Context, instanceIDs map[string][]string) ([]*common.EC2Instance, map[string]error, error) {
+	regions := sortedRegions(instanceIDs)
+	found := make([][]*common.EC2Instance, len(regions))
+	errs := make([]error, len(regions))
+
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found[i], _, errs[i] = s.GetInstancesFromClient(ctx, s.clientFor(region), instanceIDs[region])
+		}()
+	}
+	wg.Wait()
+
+	instances := make([]*common.EC2Instance, 0)
+	seen := make(map[string]bool)
+	for i, region := range regions {
+		if errs[i] != nil {
+			s.logger.Err(errs[i]).Str(common.LogStrMethod, "GetInstancesInRegions").Str("region", region).Msg("failed to describe instances")
+			return nil, nil, errs[i]
+		}
+		for _, inst := range found[i] {
+			if !seen[inst.InstanceID] {
+				seen[inst.InstanceID] = true
+				instances = append(instances, inst)
+			}
+		}
+	}
+
+	notFound := make(map[string]error)
+	for _, region := range regions {
+		for _, id := range instanceIDs[region] {
+			if !seen[id] {
+				notFound[id] = common.ErrInstanceNotFound
+			}
+		}
+	}
+
+	return instances, notFound, nil
+}
+
+// FindInstancesInRegions retrieves every EC2 instance matching the filters in each of the regions,
+// describing every region at the same time. The region "" is the default region.
+func (s *ec2Service) FindInstancesInRegions(ctx context.Context, regions []string, filters map[string][]string) ([]*common.EC2Instance, error) {
+	found := make([][]*common.EC2Instance, len(regions))
+	errs := make([]error, len(regions))
+
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found[i], errs[i] = s.FindInstancesFromClient(ctx, s.clientFor(region), filters)
+		}()
+	}
+	wg.Wait()
+
+	instances := make([]*common.EC2Instance, 0)
+	for i, region := range regions {
+		if errs[i] != nil {
+			s.logger.Err(errs[i]).Str(common.LogStrMethod, "FindInstancesInRegions").Str("region", region).Msg("failed to describe instances")
+			return nil, errs[i]
+		}
+		instances = append(instances, found[i]...)
+	}
+
+	return instances, nil
+}
+
+// clientFor returns the client of the given region, building it on first use. The region "" is the default region.
+func (s *ec2Service) clientFor(region string) EC2Client {
+	if region == "" || region == s.region || s.newClient == nil {
+		return s.client
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clients == nil {
+		s.clients = make(map[string]EC2Client)
+	}
+	client, ok := s.clients[region]
+	if !ok {
+		client = s.newClient(region)
+		s.clients[region] = client
+	}
+
+	return client
+}
+
+// RegionsForInstances groups instance IDs by the region to look them up in. An instance's region comes
+// from the availability zone the state records for it. Otherwise the ID is looked up in every one of
+// regions, or in the default region ("") when no regions are given.
+func RegionsForInstances(instanceIDs []string, stateInstances []*common.EC2Instance, regions []string) map[string][]string {
+	known := make(map[string]string, len(stateInstances))
+	for _, inst := range stateInstances {
+		if region := common.RegionFromAvailabilityZone(inst.AvailabilityZone); region != "" {
+			known[inst.InstanceID] = region
+		}
+	}
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	byRegion := make(map[string][]string)
+	for _, id := range instanceIDs {
+		if region, ok := known[id]; ok {
+			byRegion[region] = append(byRegion[region], id)
+			continue
+		}
+		for _, region := range regions {
+			byRegion[region] = append(byRegion[region], id)
+		}
+	}
+
+	return byRegion
+}
+
+// RegionsToScan returns the regions to list instances in: the given regions, or else every region
+// the state's instances live in, or else the default region ("").
+func RegionsToScan(stateInstances []*common.EC2Instance, regions []string) []string {
+	if len(regions) > 0 {
+		return regions
+	}
+
+	seen := make(map[string][]string)
+	for _, inst := range stateInstances {
+		if region := common.RegionFromAvailabilityZone(inst.AvailabilityZone); region != "" {
+			seen[region] = nil
+		}
+	}
+	if len(seen) == 0 {
+		return []string{""}
+	}
+
+	return sortedRegions(seen)
+}
+
+// sortedRegions returns the regions of m in order, so lookups and reports are deterministic.
+func sortedRegions(m map[string][]string) []string {
+	regions := make([]string, 0, len(m))
+	for region := range m {
+		regions = append(regions, region)
+	}
+	slices.Sort(regions)
+
+	return regions
+}
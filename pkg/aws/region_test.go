@@ -0,0 +1,125 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// regionalService returns a service whose default region is us-east-1, with one client per region.
+func regionalService(clients map[string]*pagedEC2Client) (*ec2Service, map[string]int) {
+	built := make(map[string]int)
+	svc := &ec2Service{
+		client: clients["us-east-1"],
+		logger: zerolog.Nop(),
+		region: "us-east-1",
+		newClient: func(region string) EC2Client {
+			built[region]++
+			return clients[region]
+		},
+	}
+
+	return svc, built
+}
+
+func liveInstances(ids ...string) []ec2Types.Instance {
+	instances := make([]ec2Types.Instance, 0, len(ids))
+	for _, id := range ids {
+		instances = append(instances, ec2Types.Instance{
+			InstanceId: common.GetStringPointer(id),
+			State:      &ec2Types.InstanceState{Name: "running"},
+		})
+	}
+
+	return instances
+}
+
+func TestGetInstancesInRegions(t *testing.T) {
+	svc, built := regionalService(map[string]*pagedEC2Client{
+		"us-east-1": {pageSize: 10, instances: liveInstances("i-use1")},
+		"eu-west-1": {pageSize: 10, instances: liveInstances("i-euw1", "i-anywhere")},
+	})
+
+	instances, notFound, err := svc.GetInstancesInRegions(context.Background(), map[string][]string{
+		"":          {"i-use1", "i-anywhere", "i-missing"},
+		"eu-west-1": {"i-euw1", "i-anywhere", "i-missing"},
+	})
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, len(instances))
+	for _, inst := range instances {
+		ids = append(ids, inst.InstanceID)
+	}
+	assert.ElementsMatch(t, []string{"i-use1", "i-euw1", "i-anywhere"}, ids)
+	assert.Equal(t, map[string]error{"i-missing": common.ErrInstanceNotFound}, notFound)
+	assert.Equal(t, map[string]int{"eu-west-1": 1}, built)
+}
+
+func TestGetInstancesInRegions_DescribeError(t *testing.T) {
+	svc, _ := regionalService(map[string]*pagedEC2Client{
+		"us-east-1": {pageSize: 10},
+		"eu-west-1": {pageSize: 10, mockEC2Client: mockEC2Client{err: assert.AnError}},
+	})
+
+	_, _, err := svc.GetInstancesInRegions(context.Background(), map[string][]string{
+		"us-east-1": {"i-1"},
+		"eu-west-1": {"i-2"},
+	})
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+}
+
+func TestFindInstancesInRegions(t *testing.T) {
+	svc, built := regionalService(map[string]*pagedEC2Client{
+		"us-east-1": {pageSize: 10, instances: liveInstances("i-use1")},
+		"eu-west-1": {pageSize: 10, instances: liveInstances("i-euw1")},
+	})
+
+	instances, err := svc.FindInstancesInRegions(context.Background(), []string{"eu-west-1", "us-east-1"}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	assert.Equal(t, "i-euw1", instances[0].InstanceID)
+	assert.Equal(t, "i-use1", instances[1].InstanceID)
+
+	// clients are built once per region
+	_, err = svc.FindInstancesInRegions(context.Background(), []string{"eu-west-1"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"eu-west-1": 1}, built)
+}
+
+func TestRegionsForInstances(t *testing.T) {
+	state := []*common.EC2Instance{
+		{InstanceID: "i-use1", AvailabilityZone: "us-east-1a"},
+		{InstanceID: "i-euw1", AvailabilityZone: "eu-west-1b"},
+		{InstanceID: "i-nozone"},
+	}
+	ids := []string{"i-use1", "i-euw1", "i-nozone", "i-unknown"}
+
+	assert.Equal(t, map[string][]string{
+		"us-east-1": {"i-use1"},
+		"eu-west-1": {"i-euw1"},
+		"":          {"i-nozone", "i-unknown"},
+	}, RegionsForInstances(ids, state, nil))
+
+	assert.Equal(t, map[string][]string{
+		"us-east-1": {"i-use1", "i-nozone", "i-unknown"},
+		"eu-west-1": {"i-euw1"},
+		"us-west-2": {"i-nozone", "i-unknown"},
+	}, RegionsForInstances(ids, state, []string{"us-east-1", "us-west-2"}))
+}
+
+func TestRegionsToScan(t *testing.T) {
+	state := []*common.EC2Instance{
+		{InstanceID: "i-1", AvailabilityZone: "eu-west-1b"},
+		{InstanceID: "i-2", AvailabilityZone: "us-east-1a"},
+		{InstanceID: "i-3", AvailabilityZone: "us-east-1c"},
+	}
+
+	assert.Equal(t, []string{"eu-west-1", "us-east-1"}, RegionsToScan(state, nil))
+	assert.Equal(t, []string{"ap-south-1"}, RegionsToScan(state, []string{"ap-south-1"}))
+	assert.Equal(t, []string{""}, RegionsToScan(nil, nil))
+}
@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+const (
+	// DefaultMaxRetries is the number of retries of a throttled or transiently failing EC2 request.
+	DefaultMaxRetries = 5
+
+	baseRetryDelay = 200 * time.Millisecond
+	maxRetryDelay  = 20 * time.Second
+)
+
+var (
+	throttleErrors  = retry.IsErrorThrottles(retry.DefaultThrottles)
+	retryableErrors = retry.IsErrorRetryables(retry.DefaultRetryables)
+)
+
+// apiStats counts the requests made through retryingClient. It is shared by every client of a service.
+type apiStats struct {
+	requests         atomic.Int64
+	retries          atomic.Int64
+	throttled        atomic.Int64
+	throttleFailures atomic.Int64
+	failures         atomic.Int64
+}
+
+// snapshot returns the current counts.
+func (s *apiStats) snapshot() common.APICallStats {
+	if s == nil {
+		return common.APICallStats{}
+	}
+
+	return common.APICallStats{
+		Requests:         s.requests.Load(),
+		Retries:          s.retries.Load(),
+		Throttled:        s.throttled.Load(),
+		ThrottleFailures: s.throttleFailures.Load(),
+		Failures:         s.failures.Load(),
+	}
+}
+
+// retryingClient wraps an EC2Client with a client-side rate limiter and retries with exponential backoff
+// and full jitter. Throttling slows the limiter down; a request still throttled after the last retry fails
+// with common.ErrAWSThrottled rather than as a plain failure.
+type retryingClient struct {
+	next       EC2Client
+	limiter    *tokenBucket
+	maxRetries int
+	stats      *apiStats
+
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+// newRetryingClient wraps next. maxRetries of 0 makes every request a single attempt.
+func newRetryingClient(next EC2Client, limiter *tokenBucket, maxRetries int, stats *apiStats) *retryingClient {
+	return &retryingClient{
+		next:       next,
+		limiter:    limiter,
+		maxRetries: maxRetries,
+		stats:      stats,
+		sleep:      sleepContext,
+		jitter:     func(d time.Duration) time.Duration { return rand.N(d + 1) },
+	}
+}
+
+// DescribeInstances calls DescribeInstances with rate limiting and retries.
+func (c *retryingClient) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	var output *ec2.DescribeInstancesOutput
+	err := c.do(ctx, func() (err error) {
+		output, err = c.next.DescribeInstances(ctx, params, optFns...)
+		return err
+	})
+
+	return output, err
+}
+
+// DescribeVolumes calls DescribeVolumes with rate limiting and retries.
+func (c *retryingClient) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	var output *ec2.DescribeVolumesOutput
+	err := c.do(ctx, func() (err error) {
+		output, err = c.next.DescribeVolumes(ctx, params, optFns...)
+		return err
+	})
+
+	return output, err
+}
+
+// do runs call until it succeeds, fails with an error that is not worth retrying, or runs out of retries.
+func (c *retryingClient) do(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		c.stats.requests.Add(1)
+		err := call()
+		if err == nil {
+			c.limiter.Succeeded()
+			return nil
+		}
+
+		throttled := throttleErrors.IsErrorThrottle(err) == aws.TrueTernary
+		if throttled {
+			c.stats.throttled.Add(1)
+			c.limiter.Throttled()
+		}
+
+		retryable := throttled || retryableErrors.IsErrorRetryable(err) == aws.TrueTernary
+		if !retryable || attempt >= c.maxRetries || ctx.Err() != nil {
+			if throttled {
+				c.stats.throttleFailures.Add(1)
+				return fmt.Errorf("%w: %w", common.ErrAWSThrottled, err)
+			}
+			c.stats.failures.Add(1)
+			return err
+		}
+
+		c.stats.retries.Add(1)
+		delay := c.jitter(min(baseRetryDelay<<attempt, maxRetryDelay))
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// describeFailure maps an EC2 error to the sentinel reported to callers, keeping throttling apart from failures.
+func describeFailure(err, failure error) error {
+	if errors.Is(err, common.ErrAWSThrottled) {
+		return common.ErrAWSThrottled
+	}
+
+	return failure
+}
+
+// TotalStats adds up the AWS API call counts of the services.
+func TotalStats(services []EC2Service) common.APICallStats {
+	var total common.APICallStats
+	for _, svc := range services {
+		stats := svc.Stats()
+		total.Requests += stats.Requests
+		total.Retries += stats.Retries
+		total.Throttled += stats.Throttled
+		total.ThrottleFailures += stats.ThrottleFailures
+		total.Failures += stats.Failures
+	}
+
+	return total
+}
@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+var errRequestLimitExceeded = &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "Request limit exceeded."}
+
+// flakyEC2Client fails the first failures calls with err, then answers like next.
+type flakyEC2Client struct {
+	next     EC2Client
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *flakyEC2Client) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return f.next.DescribeInstances(ctx, params, optFns...)
+}
+
+func (f *flakyEC2Client) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return f.next.DescribeVolumes(ctx, params, optFns...)
+}
+
+// retrying wraps next in a retryingClient that records its backoff delays instead of sleeping.
+func retrying(next EC2Client, maxRetries int) (*retryingClient, *[]time.Duration) {
+	bucket, _ := newFakeBucket(DefaultRateLimit)
+	client := newRetryingClient(next, bucket, maxRetries, &apiStats{})
+
+	var delays []time.Duration
+	client.jitter = func(d time.Duration) time.Duration { return d }
+	client.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	return client, &delays
+}
+
+func TestRetryingClient_RetriesThrottling(t *testing.T) {
+	flaky := &flakyEC2Client{next: &pagedEC2Client{pageSize: 10, instances: liveInstances("i-a")}, err: errRequestLimitExceeded, failures: 2}
+	client, delays := retrying(flaky, 3)
+
+	svc := &ec2Service{client: client, logger: zerolog.Nop(), stats: client.stats}
+	inst, err := svc.GetInstance(context.Background(), "i-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "i-a", inst.InstanceID)
+
+	assert.Equal(t, []time.Duration{baseRetryDelay, 2 * baseRetryDelay}, *delays)
+	assert.Equal(t, DefaultRateLimit/4+DefaultRateLimit/20, client.limiter.rate)
+	assert.Equal(t, common.APICallStats{Requests: 3, Retries: 2, Throttled: 2}, svc.Stats())
+}
+
+func TestRetryingClient_ThrottledAfterLastRetry(t *testing.T) {
+	flaky := &flakyEC2Client{next: &mockEC2Client{}, err: errRequestLimitExceeded, failures: 10}
+	client, _ := retrying(flaky, 2)
+
+	svc := &ec2Service{client: client, logger: zerolog.Nop(), stats: client.stats}
+	_, err := svc.GetInstance(context.Background(), "i-a")
+	assert.ErrorIs(t, err, common.ErrAWSThrottled)
+	assert.NotErrorIs(t, err, common.ErrAWSDescribeFailure)
+
+	_, _, err = svc.GetInstances(context.Background(), []string{"i-a"})
+	assert.ErrorIs(t, err, common.ErrAWSThrottled)
+
+	assert.Equal(t, 6, flaky.calls)
+	assert.Equal(t, common.APICallStats{Requests: 6, Retries: 4, Throttled: 6, ThrottleFailures: 2}, svc.Stats())
+}
+
+func TestRetryingClient_DoesNotRetryFailures(t *testing.T) {
+	flaky := &flakyEC2Client{next: &mockEC2Client{}, err: &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, failures: 10}
+	client, delays := retrying(flaky, 3)
+
+	svc := &ec2Service{client: client, logger: zerolog.Nop(), stats: client.stats}
+	_, err := svc.GetInstance(context.Background(), "i-a")
+	assert.ErrorIs(t, err, common.ErrAWSDescribeFailure)
+	assert.Equal(t, 1, flaky.calls)
+	assert.Empty(t, *delays)
+	assert.Equal(t, common.APICallStats{Requests: 1, Failures: 1}, svc.Stats())
+}
+
+func TestRetryingClient_NoRetries(t *testing.T) {
+	flaky := &flakyEC2Client{next: &mockEC2Client{}, err: errRequestLimitExceeded, failures: 10}
+	client, delays := retrying(flaky, 0)
+
+	_, err := client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{})
+	assert.ErrorIs(t, err, common.ErrAWSThrottled)
+	assert.Equal(t, 1, flaky.calls)
+	assert.Empty(t, *delays)
+}
+
+func TestRetryingClient_BackoffIsCapped(t *testing.T) {
+	flaky := &flakyEC2Client{next: &mockEC2Client{}, err: errRequestLimitExceeded, failures: 10}
+	client, delays := retrying(flaky, 9)
+
+	_, _ = client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{})
+	assert.Len(t, *delays, 9)
+	assert.Equal(t, maxRetryDelay, (*delays)[8])
+}
+
+func TestTotalStats(t *testing.T) {
+	a, b := &apiStats{}, &apiStats{}
+	a.requests.Add(3)
+	a.throttled.Add(1)
+	b.requests.Add(2)
+	b.failures.Add(1)
+
+	total := TotalStats([]EC2Service{&ec2Service{stats: a}, &ec2Service{stats: b}, &ec2Service{}})
+	assert.Equal(t, common.APICallStats{Requests: 5, Throttled: 1, Failures: 1}, total)
+}
@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// S3Client defines the subset of AWS S3 methods used by this application.
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Service defines the high-level interface for reading Terraform state stored in an S3 backend.
+type S3Service interface {
+	GetState(ctx context.Context, stateURL string) ([]byte, error)
+	GetStateFromClient(ctx context.Context, client S3Client, stateURL string) ([]byte, error)
+	OpenState(ctx context.Context, stateURL string) (io.ReadCloser, error)
+	OpenStateFromClient(ctx context.Context, client S3Client, stateURL string) (io.ReadCloser, error)
+}
+
+type s3Service struct {
+	client S3Client
+	logger zerolog.Logger
+}
+
+// NewS3Service creates a new S3Service facade using a configured AWS client.
+//
+// If opts.EndpointURL is not empty, requests are sent to it instead of the regional
+// AWS endpoint, using path-style addressing. This allows pointing the tool at
+// a local S3-compatible stand-in (e.g. MinIO or LocalStack).
+func NewS3Service(ctx context.Context, logger zerolog.Logger, opts ClientOptions) (S3Service, error) {
+	log := logger.With().Str(common.LogStrLayer, "aws").Logger()
+
+	cfg, err := opts.loadConfig(ctx)
+	if err != nil {
+		log.Err(err).Msg("unable to load AWS config")
+		return nil, common.ErrConfigLoadFailure
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.EndpointURL != "" {
+			o.UsePathStyle = true
+		}
+	})
+
+	return &s3Service{
+		client: client,
+		logger: log,
+	}, nil
+}
+
+// GetState downloads the raw Terraform state referenced by stateURL.
+func (s *s3Service) GetState(ctx context.Context, stateURL string) ([]byte, error) {
+	return s.GetStateFromClient(ctx, s.client, stateURL)
+}
+
+// GetStateFromClient downloads the raw Terraform state referenced by stateURL using the given client.
+//
+// The URL has the form s3://bucket/key. A specific object version can be
+// requested by appending ?versionId=<id>.
+func (s *s3Service) GetStateFromClient(ctx context.Context, client S3Client, stateURL string) ([]byte, error) {
+	body, err := s.OpenStateFromClient(ctx, client, stateURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(body)
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		s.logger.Err(err).Str(common.LogStrMethod, "GetStateFromClient").Str("url", stateURL).Msg("failed to read state object body")
+		return nil, common.ErrS3GetObjectFailure
+	}
+
+	return data, nil
+}
+
+// OpenState opens the raw Terraform state referenced by stateURL as a stream. The caller must close it.
+func (s *s3Service) OpenState(ctx context.Context, stateURL string) (io.ReadCloser, error) {
+	return s.OpenStateFromClient(ctx, s.client, stateURL)
+}
+
+// OpenStateFromClient opens the raw Terraform state referenced by stateURL as a stream using the given client,
+// see GetStateFromClient. The caller must close it.
+func (s *s3Service) OpenStateFromClient(ctx context.Context, client S3Client, stateURL string) (io.ReadCloser, error) {
+	log := s.logger.With().
+		Str(common.LogStrMethod, "OpenStateFromClient").
+		Str("url", stateURL).
+		Logger()
+
+	bucket, key, versionID, err := ParseS3URL(stateURL)
+	if err != nil {
+		log.Err(err).Msg("failed to parse state URL")
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: common.GetStringPointer(bucket),
+		Key:    common.GetStringPointer(key),
+	}
+	if versionID != "" {
+		input.VersionId = common.GetStringPointer(versionID)
+	}
+
+	output, err := client.GetObject(ctx, input)
+	if err != nil {
+		log.Err(err).Msg("failed to get state object")
+		return nil, common.ErrS3GetObjectFailure
+	}
+
+	return output.Body, nil
+}
+
+// ParseS3URL splits an s3://bucket/key[?versionId=id] URL into its parts.
+func ParseS3URL(raw string) (bucket, key, versionID string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "s3" {
+		return "", "", "", common.ErrInvalidStateURL
+	}
+
+	bucket = u.Host
+	key = strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return "", "", "", common.ErrInvalidStateURL
+	}
+
+	return bucket, key, u.Query().Get("versionId"), nil
+}
@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// mockS3Client implements aws.S3Client
+type mockS3Client struct {
+	body  string
+	err   error
+	input *s3.GetObjectInput
+}
+
+func (m *mockS3Client) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.input = params
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(m.body))}, nil
+}
+
+func TestGetStateFromClient_Success(t *testing.T) {
+	client := &mockS3Client{body: `{"resources":[]}`}
+	svc := &s3Service{logger: zerolog.Nop()}
+
+	data, err := svc.GetStateFromClient(context.Background(), client, "s3://my-bucket/env/prod/terraform.tfstate?versionId=v42")
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"resources":[]}`, string(data))
+	assert.Equal(t, "my-bucket", *client.input.Bucket)
+	assert.Equal(t, "env/prod/terraform.tfstate", *client.input.Key)
+	assert.Equal(t, "v42", *client.input.VersionId)
+}
+
+func TestGetStateFromClient_NoVersion(t *testing.T) {
+	client := &mockS3Client{body: "{}"}
+	svc := &s3Service{logger: zerolog.Nop()}
+
+	_, err := svc.GetStateFromClient(context.Background(), client, "s3://my-bucket/terraform.tfstate")
+
+	assert.NoError(t, err)
+	assert.Nil(t, client.input.VersionId)
+}
+
+func TestOpenStateFromClient(t *testing.T) {
+	client := &mockS3Client{body: `{"resources":[]}`}
+	svc := &s3Service{logger: zerolog.Nop()}
+
+	body, err := svc.OpenStateFromClient(context.Background(), client, "s3://my-bucket/terraform.tfstate")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, `{"resources":[]}`, string(data))
+
+	_, err = svc.OpenStateFromClient(context.Background(), client, "s3://my-bucket")
+	assert.ErrorIs(t, err, common.ErrInvalidStateURL)
+}
+
+func TestGetStateFromClient_GetObjectError(t *testing.T) {
+	client := &mockS3Client{err: assert.AnError}
+	svc := &s3Service{logger: zerolog.Nop()}
+
+	_, err := svc.GetStateFromClient(context.Background(), client, "s3://my-bucket/terraform.tfstate")
+	assert.ErrorIs(t, err, common.ErrS3GetObjectFailure)
+}
+
+func TestParseS3URL(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantBucket  string
+		wantKey     string
+		wantVersion string
+		wantErr     bool
+	}{
+		{"bucket and key", "s3://bucket/path/to/state", "bucket", "path/to/state", "", false},
+		{"with version", "s3://bucket/state?versionId=abc", "bucket", "state", "abc", false},
+		{"wrong scheme", "https://bucket/state", "", "", "", true},
+		{"missing key", "s3://bucket/", "", "", "", true},
+		{"missing bucket", "s3:///state", "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, key, version, err := ParseS3URL(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, common.ErrInvalidStateURL)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBucket, bucket)
+			assert.Equal(t, tt.wantKey, key)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+}
+
+func TestNewS3Service_CustomEndpoint(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	// a tiny S3-compatible stand-in serving a single object using path-style addressing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/state-bucket/terraform.tfstate" || r.URL.Query().Get("versionId") != "v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version":4}`))
+	}))
+	defer server.Close()
+
+	svc, err := NewS3Service(context.Background(), zerolog.Nop(), ClientOptions{EndpointURL: server.URL})
+	assert.NoError(t, err)
+
+	data, err := svc.GetState(context.Background(), "s3://state-bucket/terraform.tfstate?versionId=v1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"version":4}`, string(data))
+}
@@ -2,8 +2,10 @@ package aws
 
 import (
 	"context"
+	"path/filepath"
+	"sync"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/rs/zerolog"
 
@@ -14,26 +16,109 @@ import (
 type EC2Service interface {
 	GetInstance(ctx context.Context, instanceID string) (*common.EC2Instance, error)
 	GetInstanceFromClient(ctx context.Context, client EC2Client, instanceID string) (*common.EC2Instance, error)
+	GetInstances(ctx context.Context, instanceIDs []string) ([]*common.EC2Instance, map[string]error, error)
+	GetInstancesFromClient(ctx context.Context, client EC2Client, instanceIDs []string) ([]*common.EC2Instance, map[string]error, error)
+	FindInstances(ctx context.Context, filters map[string][]string) ([]*common.EC2Instance, error)
+	FindInstancesFromClient(ctx context.Context, client EC2Client, filters map[string][]string) ([]*common.EC2Instance, error)
+	GetInstancesInRegions(ctx context.Context, instanceIDs map[string][]string) ([]*common.EC2Instance, map[string]error, error)
+	FindInstancesInRegions(ctx context.Context, regions []string, filters map[string][]string) ([]*common.EC2Instance, error)
+	Stats() common.APICallStats
 }
 
 type ec2Service struct {
 	client EC2Client
 	logger zerolog.Logger
+
+	region    string                        // region of client
+	newClient func(region string) EC2Client // builds the client of another region
+
+	mu      sync.Mutex
+	clients map[string]EC2Client // per-region clients built so far
+
+	stats *apiStats // requests made by every client, see retryingClient
 }
 
 // NewEC2Service creates a new EC2Service facade using a configured AWS client.
-func NewEC2Service(ctx context.Context, logger zerolog.Logger) (EC2Service, error) {
+func NewEC2Service(ctx context.Context, logger zerolog.Logger, opts ClientOptions) (EC2Service, error) {
 	log := logger.With().Str(common.LogStrLayer, "aws").Logger()
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	// a replayed run never touches AWS, so it needs no configuration or credentials
+	if opts.ReplayDir != "" {
+		return newReplayEC2Service(log, opts, DefaultAccount)
+	}
+
+	cfg, err := opts.loadConfig(ctx)
 	if err != nil {
 		log.Err(err).Msg("unable to load AWS config")
 		return nil, common.ErrConfigLoadFailure
 	}
 
-	client := ec2.NewFromConfig(cfg)
+	return newEC2ServiceFromConfig(log, cfg, opts, DefaultAccount)
+}
+
+// newEC2ServiceFromConfig creates an EC2Service whose clients, in every region, use cfg. Each region's client
+// has its own rate limiter, since EC2 limits requests per account and region, and retries through
+// retryingClient instead of the SDK's retryer. With opts.RecordDir, the responses are recorded under
+// the account's directory.
+func newEC2ServiceFromConfig(log zerolog.Logger, cfg aws.Config, opts ClientOptions, account string) (*ec2Service, error) {
+	recordDir := ""
+	if opts.RecordDir != "" {
+		recordDir = filepath.Join(opts.RecordDir, account)
+		if err := writeManifest(recordDir, cfg.Region); err != nil {
+			log.Err(err).Str("dir", recordDir).Msg("unable to create recording")
+			return nil, common.ErrRecordFailure
+		}
+	}
+
+	stats := &apiStats{}
+	newClient := func(region string) EC2Client {
+		var client EC2Client = ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+			o.Region = region
+			o.Retryer = aws.NopRetryer{}
+			o.RetryMaxAttempts = 0
+		})
+		if recordDir != "" {
+			client = &recordingClient{next: client, dir: filepath.Join(recordDir, region)}
+		}
+		return newRetryingClient(client, newTokenBucket(opts.RateLimit), opts.ec2MaxRetries(), stats)
+	}
+
 	return &ec2Service{
-		client: client,
-		logger: log,
+		client:    newClient(cfg.Region),
+		logger:    log,
+		region:    cfg.Region,
+		newClient: newClient,
+		stats:     stats,
 	}, nil
 }
+
+// newReplayEC2Service creates an EC2Service serving the account's responses recorded under opts.ReplayDir.
+// Its default region is the recorded one, unless opts.Region overrides it.
+func newReplayEC2Service(log zerolog.Logger, opts ClientOptions, account string) (*ec2Service, error) {
+	dir := filepath.Join(opts.ReplayDir, account)
+	recorded, err := readManifest(dir)
+	if err != nil {
+		log.Err(err).Str("dir", dir).Msg("unable to read recording")
+		return nil, err
+	}
+
+	region := recorded.Region
+	if opts.Region != "" {
+		region = opts.Region
+	}
+	newClient := func(region string) EC2Client {
+		return &replayClient{dir: filepath.Join(dir, region)}
+	}
+
+	return &ec2Service{
+		client:    newClient(region),
+		logger:    log,
+		region:    region,
+		newClient: newClient,
+	}, nil
+}
+
+// Stats returns how many AWS API requests the service made, retried and had throttled so far.
+func (s *ec2Service) Stats() common.APICallStats {
+	return s.stats.snapshot()
+}
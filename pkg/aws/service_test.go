@@ -17,7 +17,7 @@ func TestNewEC2Service_Integration(t *testing.T) {
 
 	logger := zerolog.New(os.Stderr).With().Timestamp().Str("app", "drift-checker").Logger()
 
-	service, err := NewEC2Service(context.Background(), logger)
+	service, err := NewEC2Service(context.Background(), logger, ClientOptions{})
 	assert.NoError(t, err)
 	assert.NotNil(t, service)
 }
@@ -1,6 +1,9 @@
 package common
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrConfigLoadFailure indicates failure to load AWS configuration.
@@ -9,6 +12,28 @@ var (
 	// ErrAWSDescribeFailure indicates a failure when calling DescribeInstances.
 	ErrAWSDescribeFailure = errors.New("failed to describe EC2 instance(s)")
 
+	// ErrAWSThrottled indicates AWS kept throttling a request (e.g. RequestLimitExceeded) after every retry.
+	// It is not a failure of the request itself - try again later or lower --rate-limit.
+	ErrAWSThrottled = errors.New("AWS kept throttling requests (rate limit exceeded)")
+
+	// ErrRecordFailure indicates an AWS response could not be saved to the --record directory.
+	ErrRecordFailure = errors.New("failed to record AWS response")
+
+	// ErrRecordingNotFound indicates the --replay directory holds no recording of an account.
+	ErrRecordingNotFound = errors.New("no recording of the account found - record it first with --record")
+
+	// ErrResponseNotRecorded indicates a replayed run made a request the recorded run did not make.
+	ErrResponseNotRecorded = errors.New("AWS response not recorded")
+
+	// ErrRecordAndReplay indicates --record and --replay were both given.
+	ErrRecordAndReplay = errors.New("--record and --replay cannot be used together")
+
+	// ErrInvalidRoleARN indicates an account profile's role ARN is not an IAM role ARN with an account ID.
+	ErrInvalidRoleARN = errors.New("invalid role ARN - expected arn:aws:iam::<account-id>:role/<name>")
+
+	// ErrInvalidInstanceFilter indicates a DescribeInstances filter was not given as name=value[,value...].
+	ErrInvalidInstanceFilter = errors.New("invalid instance filter - expected name=value, e.g. tag:Env=prod")
+
 	// ErrInstanceNotFound indicates that the requested EC2 instance was not found in AWS.
 	ErrInstanceNotFound = errors.New("instance not found in AWS")
 
@@ -24,6 +49,70 @@ var (
 	// ErrPromptFailed indicates a failure in collecting user input interactively.
 	ErrPromptFailed = errors.New("interactive prompt failed")
 
-	// ErrNoInstanceIDs indicates that no instance IDs were passed or entered.
+	// ErrNoInstanceIDs indicates that no instance IDs were passed or found in the state.
 	ErrNoInstanceIDs = errors.New("no EC2 instance IDs provided")
+
+	// ErrUnsupportedStateVersion indicates the state file format version cannot be parsed. See StateVersionError.
+	ErrUnsupportedStateVersion = errors.New("unsupported Terraform state version")
+
+	// ErrNoStateFilesMatched indicates a glob or directory did not contain any .tfstate file.
+	ErrNoStateFilesMatched = errors.New("no Terraform state files matched")
+
+	// ErrUnsupportedStateSource indicates that no state source is registered for the location's scheme.
+	ErrUnsupportedStateSource = errors.New("unsupported Terraform state source")
+
+	// ErrStateFetchFailure indicates a failure when fetching state from a remote or streamed source.
+	ErrStateFetchFailure = errors.New("failed to fetch Terraform state")
+
+	// ErrInvalidPlanFile indicates the input is not `terraform show -json` output of a saved plan.
+	ErrInvalidPlanFile = errors.New("invalid Terraform plan JSON")
+
+	// ErrPlanFileConflict indicates an option that has no meaning when predicting drift from a plan was used with --plan-file.
+	ErrPlanFileConflict = errors.New("option cannot be combined with --plan-file")
+
+	// ErrNoConfigFiles indicates the configuration directory contains no .tf files.
+	ErrNoConfigFiles = errors.New("no Terraform configuration (.tf) files found")
+
+	// ErrConfigDirNotProvided indicates a mode that needs the Terraform configuration was used without --config-dir.
+	ErrConfigDirNotProvided = errors.New("terraform configuration directory not provided")
+
+	// ErrNoConfigInstances indicates no aws_instance block of --config-dir matches an instance of the state,
+	// so there is nothing to compare the configuration with.
+	ErrNoConfigInstances = errors.New("no aws_instance in the configuration matches an instance of the state")
+
+	// ErrInvalidConfig indicates the Terraform configuration or a variable file could not be parsed.
+	ErrInvalidConfig = errors.New("invalid Terraform configuration")
+
+	// ErrInvalidStateURL indicates the remote state location could not be parsed.
+	ErrInvalidStateURL = errors.New("invalid state URL - expected s3://bucket/key or tfc://organization/workspace")
+
+	// ErrS3GetObjectFailure indicates a failure when downloading the state object from S3.
+	ErrS3GetObjectFailure = errors.New("failed to fetch Terraform state from S3")
+
+	// ErrCloudTokenNotProvided indicates that no Terraform Cloud API token was configured.
+	ErrCloudTokenNotProvided = errors.New("terraform cloud API token not provided")
+
+	// ErrCloudOrganizationNotProvided indicates --tfc-workspace was given without --tfc-organization.
+	ErrCloudOrganizationNotProvided = errors.New("terraform cloud organization not provided - set --tfc-organization with --tfc-workspace")
+
+	// ErrCloudWorkspaceNotFound indicates the workspace or its state does not exist or is not visible to the token.
+	ErrCloudWorkspaceNotFound = errors.New("terraform cloud workspace or state version not found")
+
+	// ErrCloudRequestFailure indicates a failure when calling the Terraform Cloud API.
+	ErrCloudRequestFailure = errors.New("failed to fetch state from Terraform Cloud")
 )
+
+// StateVersionError is returned for a state file whose format version is not supported.
+// It matches ErrUnsupportedStateVersion with errors.Is.
+type StateVersionError struct {
+	Version int
+}
+
+func (e *StateVersionError) Error() string {
+	return fmt.Sprintf("%s: version %d (supported versions are 3 and 4)", ErrUnsupportedStateVersion, e.Version)
+}
+
+// Is makes errors.Is(err, ErrUnsupportedStateVersion) hold.
+func (e *StateVersionError) Is(target error) bool {
+	return target == ErrUnsupportedStateVersion
+}
@@ -2,7 +2,14 @@
 package common
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -42,8 +49,33 @@ func ExtractBlockDevices(value interface{}) []BlockDeviceMapping {
 		for _, item := range list {
 			if m, ok := item.(map[string]interface{}); ok {
 				result = append(result, BlockDeviceMapping{
-					DeviceName: ToString(m["device_name"]),
-					VolumeID:   ToString(m["volume_id"]),
+					DeviceName:          ToString(m["device_name"]),
+					VolumeID:            ToString(m["volume_id"]),
+					VolumeSize:          ToInt(m["volume_size"]),
+					VolumeType:          ToString(m["volume_type"]),
+					Iops:                ToInt(m["iops"]),
+					Throughput:          ToInt(m["throughput"]),
+					Encrypted:           ToBool(m["encrypted"]),
+					KmsKeyID:            ToString(m["kms_key_id"]),
+					DeleteOnTermination: ToBool(m["delete_on_termination"]),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// ExtractEphemeralBlockDevices parses the ephemeral_block_device attribute of a Terraform state.
+// Entries with no_device set only suppress a device from the AMI and are skipped.
+func ExtractEphemeralBlockDevices(value interface{}) []BlockDeviceMapping {
+	var result []BlockDeviceMapping
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok && !ToBool(m["no_device"]) {
+				result = append(result, BlockDeviceMapping{
+					DeviceName:  ToString(m["device_name"]),
+					VirtualName: ToString(m["virtual_name"]),
 				})
 			}
 		}
@@ -83,6 +115,21 @@ func ToString(value interface{}) string {
 	return ""
 }
 
+// ToInt attempts to convert an interface{} to an int.
+// JSON numbers (float64), ints and numeric strings are accepted; anything else returns 0.
+func ToInt(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
+
 // ToBool attempts to convert an interface{} to a bool.
 // If the value is not a boolean, it returns false.
 func ToBool(value interface{}) bool {
@@ -117,3 +164,63 @@ func ToMap(list []string) map[string]bool {
 
 	return m
 }
+
+// ResourceAddress builds the full Terraform address of a resource instance,
+// e.g. module.web.aws_instance.app[2] or data.aws_instance.lookup["blue"].
+//
+// indexKey is the instance's index_key from the state: nil for a single
+// instance, a number for count and a string for for_each.
+func ResourceAddress(module, mode, resourceType, name string, indexKey interface{}) string {
+	var sb strings.Builder
+	if module != "" {
+		sb.WriteString(module)
+		sb.WriteString(".")
+	}
+	if mode == "data" {
+		sb.WriteString("data.")
+	}
+	sb.WriteString(resourceType)
+	sb.WriteString(".")
+	sb.WriteString(name)
+
+	switch key := indexKey.(type) {
+	case string:
+		sb.WriteString(fmt.Sprintf("[%q]", key))
+	case float64:
+		sb.WriteString(fmt.Sprintf("[%d]", int64(key)))
+	case int:
+		sb.WriteString(fmt.Sprintf("[%d]", key))
+	}
+
+	return sb.String()
+}
+
+// sensitiveHashKey keys the hashes of masked values. It is random per run, so a
+// hash tells whether two values differ without allowing offline guessing.
+var sensitiveHashKey = func() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}()
+
+// MaskSensitive replaces a sensitive value with a keyed hash of it, e.g. "(sensitive, hash 1a2b3c4d5e6f)".
+// Equal values give equal masks within a run, so a report still shows that two values differ.
+func MaskSensitive(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", value))
+	}
+
+	mac := hmac.New(sha256.New, sensitiveHashKey)
+	mac.Write(data)
+	return fmt.Sprintf("(sensitive, hash %s)", hex.EncodeToString(mac.Sum(nil))[:12])
+}
+
+// regionPattern matches the region at the start of an availability zone name, e.g. "us-east-1" in
+// "us-east-1a", "us-west-2-lax-1a" (Local Zone) or "us-gov-west-1b".
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+`)
+
+// RegionFromAvailabilityZone returns the region an availability zone belongs to, or "" when unknown.
+func RegionFromAvailabilityZone(zone string) string {
+	return regionPattern.FindString(zone)
+}
@@ -249,3 +249,67 @@ func TestGetStringPointer(t *testing.T) {
 	assert.NotNil(t, ptr)
 	assert.Equal(t, "test", *ptr)
 }
+
+func TestResourceAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   string
+		mode     string
+		indexKey interface{}
+		want     string
+	}{
+		{"root single", "", "managed", nil, "aws_instance.app"},
+		{"count in module", "module.web", "managed", float64(2), "module.web.aws_instance.app[2]"},
+		{"for_each in nested module", "module.web.module.blue", "managed", "a", `module.web.module.blue.aws_instance.app["a"]`},
+		{"data source", "", "data", nil, "data.aws_instance.app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResourceAddress(tt.module, tt.mode, "aws_instance", "app", tt.indexKey))
+		})
+	}
+}
+
+func TestMaskSensitive(t *testing.T) {
+	a := MaskSensitive("s3cr3t")
+	b := MaskSensitive("other")
+
+	assert.NotContains(t, a, "s3cr3t")
+	assert.Equal(t, a, MaskSensitive("s3cr3t"), "equal values must give equal masks")
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, MaskSensitive(map[string]string{"a": "1", "b": "2"}), MaskSensitive(map[string]string{"b": "2", "a": "1"}))
+}
+
+func TestExtractEphemeralBlockDevices(t *testing.T) {
+	got := ExtractEphemeralBlockDevices([]interface{}{
+		map[string]interface{}{"device_name": "/dev/sdb", "virtual_name": "ephemeral0"},
+		map[string]interface{}{"device_name": "/dev/sdc", "no_device": true},
+	})
+	assert.Equal(t, []BlockDeviceMapping{{DeviceName: "/dev/sdb", VirtualName: "ephemeral0"}}, got)
+	assert.Nil(t, ExtractEphemeralBlockDevices(nil))
+}
+
+func TestToInt(t *testing.T) {
+	assert.Equal(t, 8, ToInt(float64(8)))
+	assert.Equal(t, 3, ToInt(3))
+	assert.Equal(t, 125, ToInt("125"))
+	assert.Equal(t, 0, ToInt("abc"))
+	assert.Equal(t, 0, ToInt(nil))
+}
+
+func TestRegionFromAvailabilityZone(t *testing.T) {
+	cases := map[string]string{
+		"us-east-1a":              "us-east-1",
+		"eu-west-1c":              "eu-west-1",
+		"ap-southeast-2b":         "ap-southeast-2",
+		"us-gov-west-1a":          "us-gov-west-1",
+		"us-west-2-lax-1a":        "us-west-2",
+		"us-east-1-wl1-bos-wlz-1": "us-east-1",
+		"":                        "",
+		"unknown":                 "",
+	}
+	for zone, want := range cases {
+		assert.Equal(t, want, RegionFromAvailabilityZone(zone), zone)
+	}
+}
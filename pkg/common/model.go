@@ -1,9 +1,15 @@
 package common
 
+import "time"
+
 type (
 	// EC2Instance holds the configuration details for an EC2 instance.
 	EC2Instance struct {
 		InstanceID          string
+		AccountID           string // AWS account owning the instance; for state entries, taken from the recorded ARN
+		Address             string // full Terraform resource address, e.g. module.web.aws_instance.app[2]
+		ObservedOnly        bool   // read through a data source rather than managed by the state
+		Origin              string // state file or workspace the instance was read from
 		InstanceType        string
 		ImageID             string
 		KeyName             string
@@ -15,42 +21,254 @@ type (
 		VpcID               string
 		SecurityGroups      []string
 		Tags                map[string]string
+		TagsAll             map[string]string // tags including provider default_tags; nil when not known
 		BlockDeviceMappings []BlockDeviceMapping
+		EphemeralBlocks     []BlockDeviceMapping // instance store volumes; DescribeInstances does not report them
 		IamInstanceProfile  string
 		Monitoring          bool
 		Architecture        string
 		VirtualizationType  string
+		Unknown             map[string]bool // attributes that could not be resolved from configuration
+		Sensitive           map[string]bool // attributes Terraform marks as sensitive, masked in reports
+		LaunchTime          time.Time       // only known for live instances
+
+		// VolumeSettingsUnknown is set on a live instance when DescribeVolumes failed: the EBS settings
+		// of its volumes were not read, and only delete_on_termination is compared.
+		VolumeSettingsUnknown bool
 	}
 
 	// BlockDeviceMapping represents the mapping of a block device.
 	BlockDeviceMapping struct {
-		DeviceName string
-		VolumeID   string
+		DeviceName  string
+		VolumeID    string
+		VirtualName string // instance store name (ephemeral0, ...) for ephemeral devices
+		Attached    bool   // added from an aws_volume_attachment, which records no volume settings
+
+		// EBS volume settings, compared per device
+		VolumeSize          int // GiB
+		VolumeType          string
+		Iops                int
+		Throughput          int // MiB/s
+		Encrypted           bool
+		KmsKeyID            string
+		DeleteOnTermination bool
 	}
 
 	// FieldDiff holds the values of a field that differ between AWS and Terraform.
+	//
+	// In three-way mode, Terraform holds the state value, Config the value from
+	// the .tf configuration and Category tells where the difference comes from.
 	FieldDiff struct {
-		AWS       any `json:"aws"`
-		Terraform any `json:"terraform"`
+		AWS       any          `json:"aws"`
+		Terraform any          `json:"terraform"`
+		Config    any          `json:"config,omitempty"`
+		Category  DiffCategory `json:"category,omitempty"`
+
+		// TagChanges explains a tags difference key by key.
+		TagChanges map[string]TagChange `json:"tag_changes,omitempty"`
+
+		// Sensitive is set when AWS and Terraform hold masked values, see MaskSensitive.
+		Sensitive bool `json:"sensitive,omitempty"`
 	}
 
+	// TagChange tells why a single tag differs between AWS and Terraform.
+	TagChange string
+
+	// DiffCategory classifies a three-way difference between configuration, state and live AWS.
+	DiffCategory string
+
+	// DriftKind tells what kind of drift a result reports when it is more than a set of field differences.
+	DriftKind string
+
 	// DriftResult summarizes the differences found for an EC2 instance.
 	DriftResult struct {
 		InstanceID    string               `json:"instance_id"`
+		AccountID     string               `json:"account_id,omitempty"`
+		Kind          DriftKind            `json:"kind,omitempty"`
+		Address       string               `json:"address,omitempty"`
+		ObservedOnly  bool                 `json:"observed_only,omitempty"`
+		Origin        string               `json:"origin,omitempty"`
+		PlannedAction string               `json:"planned_action,omitempty"`
+		Unknown       []string             `json:"unknown,omitempty"`
 		DriftDetected bool                 `json:"drift_detected"`
 		Differences   map[string]FieldDiff `json:"differences"`
+
+		// VolumeSettingsUnknown is set when the live EBS volume settings could not be read, so only
+		// delete_on_termination of the block devices was compared.
+		VolumeSettingsUnknown bool `json:"volume_settings_unknown,omitempty"`
+	}
+
+	// UnmanagedInstance is a live EC2 instance that no loaded Terraform state manages.
+	UnmanagedInstance struct {
+		InstanceID       string            `json:"instance_id"`
+		AccountID        string            `json:"account_id,omitempty"`
+		InstanceType     string            `json:"instance_type"`
+		State            string            `json:"state"`
+		AvailabilityZone string            `json:"availability_zone,omitempty"`
+		VpcID            string            `json:"vpc_id,omitempty"`
+		LaunchTime       time.Time         `json:"launch_time"`
+		Tags             map[string]string `json:"tags"`
+	}
+
+	// APICallStats counts the AWS API requests made during a run.
+	APICallStats struct {
+		Requests         int64 `json:"requests"`          // attempts sent, retries included
+		Retries          int64 `json:"retries"`           // attempts made again after a throttle or a transient error
+		Throttled        int64 `json:"throttled"`         // attempts AWS rejected with a throttling error
+		ThrottleFailures int64 `json:"throttle_failures"` // requests still throttled after the last retry
+		Failures         int64 `json:"failures"`          // requests that failed for any other reason
+	}
+
+	// RunSummary sums up a run: what was checked and how the AWS API coped.
+	RunSummary struct {
+		Instances    int          `json:"instances"`      // managed instances checked, observed-only ones left out
+		Drifted      int          `json:"drifted"`        // instances with drift, including those missing in AWS
+		MissingInAWS int          `json:"missing_in_aws"` // drifted instances the state has but AWS does not
+		API          APICallStats `json:"api"`
+	}
+
+	// StateConflict records an instance ID managed by more than one Terraform state.
+	StateConflict struct {
+		InstanceID string   `json:"instance_id"`
+		Origins    []string `json:"origins"`
+	}
+
+	// PlannedChange is what a saved Terraform plan intends to do with an existing EC2 instance.
+	//
+	// Before holds the values the plan was made against, After the values it will apply.
+	// Attributes only known after apply are listed in After.Unknown.
+	PlannedChange struct {
+		Action string
+		Before *EC2Instance
+		After  *EC2Instance
 	}
 
 	// TerraformState represents the structure of a Terraform state file.
 	TerraformState struct {
-		Resources []struct {
-			Type      string `json:"type"`
-			Name      string `json:"name"`
-			Instances []struct {
-				Attributes map[string]interface{} `json:"attributes"`
-			} `json:"instances"`
-		} `json:"resources"`
+		Resources []TerraformStateResource `json:"resources"`
 	}
+
+	// TerraformStateResource is a resource in a Terraform state file, with one entry per count/for_each instance.
+	TerraformStateResource struct {
+		Module    string                   `json:"module"`
+		Mode      string                   `json:"mode"`
+		Type      string                   `json:"type"`
+		Name      string                   `json:"name"`
+		Instances []TerraformStateInstance `json:"instances"`
+	}
+
+	// TerraformStateInstance is a single instance of a resource in a Terraform state file.
+	TerraformStateInstance struct {
+		IndexKey            interface{}            `json:"index_key"`
+		Attributes          map[string]interface{} `json:"attributes"`
+		SensitiveAttributes []interface{}          `json:"sensitive_attributes"`
+	}
+
+	// LegacyTerraformState represents the structure of a version 3 state file (Terraform 0.11 and older).
+	// Resources are keyed by "[data.]type.name[.index]" and attributes are flattened to strings.
+	LegacyTerraformState struct {
+		Modules []struct {
+			Path      []string `json:"path"`
+			Resources map[string]struct {
+				Type    string `json:"type"`
+				Primary *struct {
+					ID         string            `json:"id"`
+					Attributes map[string]string `json:"attributes"`
+				} `json:"primary"`
+			} `json:"resources"`
+		} `json:"modules"`
+	}
+
+	// TerraformPlan represents the structure produced by `terraform show -json` for a saved plan.
+	TerraformPlan struct {
+		FormatVersion   string `json:"format_version"`
+		ResourceChanges []struct {
+			Address string      `json:"address"`
+			Mode    string      `json:"mode"`
+			Type    string      `json:"type"`
+			Name    string      `json:"name"`
+			Index   interface{} `json:"index"`
+			Change  struct {
+				Actions         []string               `json:"actions"`
+				Before          map[string]interface{} `json:"before"`
+				After           map[string]interface{} `json:"after"`
+				AfterUnknown    map[string]interface{} `json:"after_unknown"`
+				BeforeSensitive interface{}            `json:"before_sensitive"`
+				AfterSensitive  interface{}            `json:"after_sensitive"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+
+	// TerraformShowOutput represents the structure produced by `terraform show -json`.
+	TerraformShowOutput struct {
+		FormatVersion string `json:"format_version"`
+		Values        *struct {
+			RootModule TerraformModule `json:"root_module"`
+		} `json:"values"`
+	}
+
+	// TerraformModule is a module in `terraform show -json` output. Child modules nest recursively.
+	TerraformModule struct {
+		Address      string              `json:"address"`
+		Resources    []TerraformResource `json:"resources"`
+		ChildModules []TerraformModule   `json:"child_modules"`
+	}
+
+	// TerraformResource is a single resource instance in `terraform show -json` output.
+	TerraformResource struct {
+		Address string                 `json:"address"`
+		Mode    string                 `json:"mode"`
+		Type    string                 `json:"type"`
+		Name    string                 `json:"name"`
+		Index   interface{}            `json:"index"`
+		Values  map[string]interface{} `json:"values"`
+
+		SensitiveValues map[string]interface{} `json:"sensitive_values"`
+	}
+)
+
+const (
+	// CategoryChangedOutsideTerraform means configuration and state agree but live AWS differs - investigate a manual change.
+	CategoryChangedOutsideTerraform DiffCategory = "changed_outside_terraform"
+	// CategoryPendingApply means the configuration differs from the state while live AWS still matches the state - run apply.
+	CategoryPendingApply DiffCategory = "pending_apply"
+	// CategoryBoth means the configuration differs from the state and live AWS differs from the state too.
+	CategoryBoth DiffCategory = "both"
+	// CategoryUnknownInConfig means live AWS differs from the state but the configuration value could not be resolved,
+	// so it is not known whether the configuration matches either.
+	CategoryUnknownInConfig DiffCategory = "unknown_in_config"
+)
+
+const (
+	// KindMissingInAWS means the instance is in the Terraform state but was deleted or terminated in AWS.
+	KindMissingInAWS DriftKind = "missing_in_aws"
+)
+
+// InstanceStateTerminated is the EC2 state of a deleted instance. DescribeInstances keeps returning
+// terminated instances for up to an hour.
+const InstanceStateTerminated = "terminated"
+
+const (
+	// TagDefaultMissing means a tag from the provider's default_tags is not on the live resource.
+	TagDefaultMissing TagChange = "default_tag_missing"
+	// TagDefaultChanged means a tag from the provider's default_tags has a different live value.
+	TagDefaultChanged TagChange = "default_tag_changed"
+	// TagResourceMissing means a tag set on the resource itself is not on the live resource.
+	TagResourceMissing TagChange = "resource_tag_missing"
+	// TagResourceChanged means a tag set on the resource itself has a different live value.
+	TagResourceChanged TagChange = "resource_tag_changed"
+	// TagUnexpected means the live resource has a tag Terraform does not know about.
+	TagUnexpected TagChange = "unexpected_tag"
+)
+
+// Actions of a planned change, as reported in resource_changes[].change.actions.
+const (
+	PlanActionNoOp    = "no-op"
+	PlanActionCreate  = "create"
+	PlanActionRead    = "read"
+	PlanActionUpdate  = "update"
+	PlanActionDelete  = "delete"
+	PlanActionReplace = "replace" // delete and create, in either order
 )
 
 var (
@@ -21,6 +21,9 @@ import (
 func compareInstances(awsInst, tfInst *common.EC2Instance, filter map[string]bool) common.DriftResult {
 	result := common.DriftResult{
 		InstanceID:  awsInst.InstanceID,
+		AccountID:   awsInst.AccountID,
+		Address:     tfInst.Address,
+		Origin:      tfInst.Origin,
 		Differences: make(map[string]common.FieldDiff),
 	}
 
@@ -41,9 +44,7 @@ func compareInstances(awsInst, tfInst *common.EC2Instance, filter map[string]boo
 	compareField("virtualization_type", awsInst.VirtualizationType, tfInst.VirtualizationType, filter, result.Differences)
 
 	// then, we do for the tags
-	if shouldCompare("tags") && !reflect.DeepEqual(awsInst.Tags, tfInst.Tags) {
-		compareMap("tags", awsInst.Tags, tfInst.Tags, filter, result.Differences)
-	}
+	compareTags(awsInst, tfInst, filter, result.Differences)
 
 	// time for security groups (SGs)
 	if shouldCompare("security_groups") {
@@ -65,12 +66,93 @@ func compareInstances(awsInst, tfInst *common.EC2Instance, filter map[string]boo
 		if !slices.Equal(awsBdm, tfBdm) {
 			compareSlice("block_device_mappings", awsBdm, tfBdm, filter, result.Differences)
 		}
+		compareBlockDeviceSettings(awsInst.BlockDeviceMappings, tfInst.BlockDeviceMappings, awsInst.VolumeSettingsUnknown, result.Differences)
+		result.VolumeSettingsUnknown = awsInst.VolumeSettingsUnknown
+	}
+
+	// attributes the configuration could not resolve are reported as unknown rather than as drift
+	for field := range tfInst.Unknown {
+		if !shouldCompare(field) {
+			continue
+		}
+		for key := range result.Differences {
+			if fieldOf(key) == field {
+				delete(result.Differences, key)
+			}
+		}
+		result.Unknown = append(result.Unknown, field)
+	}
+	sort.Strings(result.Unknown)
+
+	// sensitive values are compared as usual, but only their masks ever leave the engine
+	for key, diff := range result.Differences {
+		field := fieldOf(key)
+		if !tfInst.Sensitive[field] && !awsInst.Sensitive[field] {
+			continue
+		}
+		result.Differences[key] = common.FieldDiff{
+			AWS:       common.MaskSensitive(diff.AWS),
+			Terraform: common.MaskSensitive(diff.Terraform),
+			Sensitive: true,
+		}
 	}
 
 	result.DriftDetected = len(result.Differences) > 0
 	return result
 }
 
+// compareBlockDeviceSettings compares the EBS settings of every device present on both sides,
+// reporting each difference as block_device_mappings[<device>].<setting>.
+// Devices missing on either side are already covered by the block_device_mappings list diff.
+//
+// Only settings Terraform records are compared: a zero size, IOPS or throughput and an empty type or
+// KMS key mean Terraform leaves the setting to AWS. Devices only known from an aws_volume_attachment
+// record no settings at all and are skipped. When the live volume settings could not be read
+// (volumeSettingsUnknown), only delete_on_termination, which DescribeInstances reports, is compared.
+func compareBlockDeviceSettings(awsDevices, tfDevices []common.BlockDeviceMapping, volumeSettingsUnknown bool, out map[string]common.FieldDiff) {
+	awsByName := make(map[string]common.BlockDeviceMapping, len(awsDevices))
+	for _, d := range awsDevices {
+		awsByName[d.DeviceName] = d
+	}
+
+	for _, tfDev := range tfDevices {
+		awsDev, ok := awsByName[tfDev.DeviceName]
+		if !ok || tfDev.Attached {
+			continue
+		}
+
+		prefix := fmt.Sprintf("block_device_mappings[%s].", tfDev.DeviceName)
+		compareField(prefix+"delete_on_termination", awsDev.DeleteOnTermination, tfDev.DeleteOnTermination, nil, out)
+		if volumeSettingsUnknown {
+			continue
+		}
+		compareSetting(prefix+"volume_size", awsDev.VolumeSize, tfDev.VolumeSize, out)
+		compareSetting(prefix+"volume_type", awsDev.VolumeType, tfDev.VolumeType, out)
+		compareSetting(prefix+"iops", awsDev.Iops, tfDev.Iops, out)
+		compareSetting(prefix+"throughput", awsDev.Throughput, tfDev.Throughput, out)
+		compareField(prefix+"encrypted", awsDev.Encrypted, tfDev.Encrypted, nil, out)
+		compareSetting(prefix+"kms_key_id", awsDev.KmsKeyID, tfDev.KmsKeyID, out)
+	}
+}
+
+// compareSetting compares a volume setting unless Terraform leaves it unset (the zero value).
+func compareSetting[T comparable](field string, a, b T, out map[string]common.FieldDiff) {
+	var unset T
+	if b == unset {
+		return
+	}
+	compareField(field, a, b, nil, out)
+}
+
+// fieldOf returns the attribute a difference key belongs to, e.g. block_device_mappings
+// for block_device_mappings[/dev/xvda].volume_size.
+func fieldOf(key string) string {
+	if i := strings.IndexAny(key, "[."); i > 0 {
+		return key[:i]
+	}
+	return key
+}
+
 // compareField performs a type-safe equality check between two values of comparable type T.
 // If the specified field is included in the comparison filter (or no filter is set),
 // and the values differ, the difference is added to the output map.
@@ -98,6 +180,54 @@ func compareMap(field string, a, b map[string]string, filter map[string]bool, ou
 	}
 }
 
+// compareTags compares tags.
+//
+// When the Terraform side knows tags_all (resource tags merged with the provider's
+// default_tags), that is what live AWS is compared against, so default tags are not
+// reported as drift, and each differing key is explained in the diff's TagChanges.
+// Otherwise only the resource's own tags are compared.
+func compareTags(awsInst, tfInst *common.EC2Instance, filter map[string]bool, out map[string]common.FieldDiff) {
+	awsTags, tfTags := awsInst.Tags, tfInst.Tags
+	if tfInst.TagsAll != nil {
+		tfTags = tfInst.TagsAll
+		if awsInst.TagsAll != nil {
+			// both sides come from Terraform, e.g. the before and after values of a plan
+			awsTags = awsInst.TagsAll
+		}
+	}
+	if len(filter) > 0 && !filter["tags"] || reflect.DeepEqual(awsTags, tfTags) {
+		return
+	}
+
+	compareMap("tags", awsTags, tfTags, filter, out)
+	diff, ok := out["tags"]
+	if !ok || tfInst.TagsAll == nil {
+		return
+	}
+
+	diff.TagChanges = make(map[string]common.TagChange)
+	for key, want := range tfTags {
+		_, isResourceTag := tfInst.Tags[key]
+		got, ok := awsTags[key]
+		switch {
+		case !ok && isResourceTag:
+			diff.TagChanges[key] = common.TagResourceMissing
+		case !ok:
+			diff.TagChanges[key] = common.TagDefaultMissing
+		case got != want && isResourceTag:
+			diff.TagChanges[key] = common.TagResourceChanged
+		case got != want:
+			diff.TagChanges[key] = common.TagDefaultChanged
+		}
+	}
+	for key := range awsTags {
+		if _, ok := tfTags[key]; !ok {
+			diff.TagChanges[key] = common.TagUnexpected
+		}
+	}
+	out["tags"] = diff
+}
+
 // compareSlice compares two slices of strings for equality regardless of order.
 // If the specified field is included in the comparison filter (or no filter is set),
 // and the sorted slices differ, the difference is added to the output map.
@@ -139,9 +269,15 @@ func CompareAllInstances(ctx context.Context, awsInstances []*common.EC2Instance
 	resultsCh := make(chan common.DriftResult)
 	tasks := make(chan *common.EC2Instance)
 
-	// Build a map for quick lookup
+	// Build a map for quick lookup. data sources don't own the instance, so they never satisfy a managed lookup,
+	// but an instance the state observes is not missing from it either (see CompareObservedInstances)
 	tfMap := make(map[string]*common.EC2Instance)
+	observed := make(map[string]bool)
 	for _, tfInst := range tfInstances {
+		if tfInst.ObservedOnly {
+			observed[tfInst.InstanceID] = true
+			continue
+		}
 		tfMap[tfInst.InstanceID] = tfInst
 	}
 
@@ -161,9 +297,20 @@ func CompareAllInstances(ctx context.Context, awsInstances []*common.EC2Instance
 						return
 					}
 					tfInst, ok := tfMap[awsInst.InstanceID]
+					if awsInst.State == common.InstanceStateTerminated {
+						// a terminated instance is gone: drift only if the state still manages it
+						if ok {
+							resultsCh <- missingInAWS(tfInst, awsInst)
+						}
+						continue
+					}
+					if !ok && observed[awsInst.InstanceID] {
+						continue
+					}
 					if !ok {
 						resultsCh <- common.DriftResult{
 							InstanceID:    awsInst.InstanceID,
+							AccountID:     awsInst.AccountID,
 							DriftDetected: true,
 							Differences: map[string]common.FieldDiff{
 								"terraform_state": {
@@ -204,6 +351,303 @@ func CompareAllInstances(ctx context.Context, awsInstances []*common.EC2Instance
 	return results
 }
 
+// CompareThreeWay compares the Terraform configuration, the Terraform state and
+// live AWS, and classifies every field difference:
+//
+//   - changed_outside_terraform: configuration == state, live AWS differs
+//   - pending_apply: configuration differs from state, live AWS matches state
+//   - both: configuration differs from state and live AWS differs from state
+//   - unknown_in_config: live AWS differs from state, the configuration value could not be resolved
+//
+// Instances are matched by ID, so configInstances must carry the IDs taken from
+// the state (see terraform.AttachInstanceIDs). A state instance without any
+// configuration block is reported as pending apply (it would be destroyed).
+func CompareThreeWay(ctx context.Context, awsInstances, stateInstances, configInstances []*common.EC2Instance, filter map[string]bool) []common.DriftResult {
+	results := CompareAllInstances(ctx, awsInstances, stateInstances, filter)
+
+	stateMap := make(map[string]*common.EC2Instance, len(stateInstances))
+	for _, inst := range stateInstances {
+		if !inst.ObservedOnly {
+			stateMap[inst.InstanceID] = inst
+		}
+	}
+	configMap := make(map[string]*common.EC2Instance, len(configInstances))
+	for _, inst := range configInstances {
+		configMap[inst.InstanceID] = inst
+	}
+
+	for i := range results {
+		result := &results[i]
+		stateInst, ok := stateMap[result.InstanceID]
+		if !ok || result.Kind == common.KindMissingInAWS {
+			// not in the state at all, or not in AWS anymore: nothing to classify
+			continue
+		}
+
+		configInst, ok := configMap[result.InstanceID]
+		if !ok {
+			result.Differences["terraform_config"] = common.FieldDiff{
+				AWS:       "exists",
+				Terraform: "exists",
+				Config:    "missing",
+				Category:  common.CategoryPendingApply,
+			}
+			result.DriftDetected = true
+			continue
+		}
+
+		// live AWS vs state is already in the result, now compute state vs configuration
+		pending := compareInstances(stateInst, configInst, filter)
+		unknown := common.ToMap(pending.Unknown)
+		for field, diff := range result.Differences {
+			if unknown[fieldOf(field)] {
+				diff.Category = common.CategoryUnknownInConfig
+				diff.Config = nil
+				result.Differences[field] = diff
+				continue
+			}
+			diff.Category = common.CategoryChangedOutsideTerraform
+			diff.Config = diff.Terraform
+			if configDiff, ok := pending.Differences[field]; ok {
+				diff.Category = common.CategoryBoth
+				diff.Config = configDiff.Terraform
+			}
+			result.Differences[field] = diff
+		}
+		for field, configDiff := range pending.Differences {
+			if _, ok := result.Differences[field]; ok {
+				continue
+			}
+			result.Differences[field] = common.FieldDiff{
+				AWS:       configDiff.AWS, // live matches state here
+				Terraform: configDiff.AWS,
+				Config:    configDiff.Terraform,
+				Category:  common.CategoryPendingApply,
+				Sensitive: configDiff.Sensitive,
+			}
+		}
+
+		result.Unknown = pending.Unknown
+		result.DriftDetected = len(result.Differences) > 0
+	}
+
+	return results
+}
+
+// CompareConfig compares live AWS against the Terraform configuration instead of the state.
+//
+// configInstances must carry the IDs taken from the state (see terraform.AttachInstanceIDs).
+// A state instance whose configuration block was removed is reported the way CompareThreeWay
+// does, as pending apply (it would be destroyed), rather than as missing from Terraform.
+func CompareConfig(ctx context.Context, awsInstances, stateInstances, configInstances []*common.EC2Instance, filter map[string]bool) []common.DriftResult {
+	stateMap := make(map[string]*common.EC2Instance, len(stateInstances))
+	for _, inst := range stateInstances {
+		if !inst.ObservedOnly {
+			stateMap[inst.InstanceID] = inst
+		}
+	}
+	configMap := make(map[string]*common.EC2Instance, len(configInstances))
+	for _, inst := range configInstances {
+		configMap[inst.InstanceID] = inst
+	}
+
+	var configured []*common.EC2Instance
+	var removed []common.DriftResult
+	for _, awsInst := range awsInstances {
+		stateInst, ok := stateMap[awsInst.InstanceID]
+		if _, inConfig := configMap[awsInst.InstanceID]; inConfig || !ok {
+			configured = append(configured, awsInst)
+			continue
+		}
+		if awsInst.State == common.InstanceStateTerminated {
+			removed = append(removed, missingInAWS(stateInst, awsInst))
+			continue
+		}
+		removed = append(removed, common.DriftResult{
+			InstanceID:    awsInst.InstanceID,
+			AccountID:     awsInst.AccountID,
+			Address:       stateInst.Address,
+			Origin:        stateInst.Origin,
+			DriftDetected: true,
+			Differences: map[string]common.FieldDiff{
+				"terraform_config": {
+					AWS:       "exists",
+					Terraform: "exists",
+					Config:    "missing",
+					Category:  common.CategoryPendingApply,
+				},
+			},
+		})
+	}
+
+	// observed instances are passed along so they are not reported as missing from Terraform
+	var compared []*common.EC2Instance
+	compared = append(compared, configInstances...)
+	for _, inst := range stateInstances {
+		if inst.ObservedOnly {
+			compared = append(compared, inst)
+		}
+	}
+
+	return append(CompareAllInstances(ctx, configured, compared, filter), removed...)
+}
+
+// FindMissingInstances reports the requested instances that the state manages but AWS did not return
+// at all, i.e. deleted long enough ago that DescribeInstances no longer lists them.
+// Terminated instances AWS still returns are reported by CompareAllInstances.
+func FindMissingInstances(instanceIDs []string, awsInstances, tfInstances []*common.EC2Instance) []common.DriftResult {
+	live := make(map[string]bool, len(awsInstances))
+	for _, awsInst := range awsInstances {
+		live[awsInst.InstanceID] = true
+	}
+	tfMap := make(map[string]*common.EC2Instance, len(tfInstances))
+	for _, tfInst := range tfInstances {
+		if !tfInst.ObservedOnly {
+			tfMap[tfInst.InstanceID] = tfInst
+		}
+	}
+
+	results := make([]common.DriftResult, 0)
+	for _, id := range instanceIDs {
+		tfInst, ok := tfMap[id]
+		if !ok || live[id] {
+			continue
+		}
+		results = append(results, missingInAWS(tfInst, nil))
+		live[id] = true // report each instance once
+	}
+
+	return results
+}
+
+// missingInAWS builds the result for an instance the state manages but AWS no longer has: awsInst is the
+// terminated instance AWS still returns, or nil when AWS does not know the instance at all. The account
+// is the live instance's, else the one the state recorded.
+func missingInAWS(tfInst, awsInst *common.EC2Instance) common.DriftResult {
+	awsState, accountID := "missing", tfInst.AccountID
+	if awsInst != nil {
+		awsState, accountID = awsInst.State, awsInst.AccountID
+	}
+
+	return common.DriftResult{
+		InstanceID:    tfInst.InstanceID,
+		AccountID:     accountID,
+		Kind:          common.KindMissingInAWS,
+		Address:       tfInst.Address,
+		Origin:        tfInst.Origin,
+		DriftDetected: true,
+		Differences: map[string]common.FieldDiff{
+			"instance": {
+				AWS:       awsState,
+				Terraform: "exists",
+			},
+		},
+	}
+}
+
+// MarkConflicts flags the results for instances managed by more than one Terraform
+// state with a "terraform_state" difference listing every state that claims them.
+// Two states applying their own view of one instance is drift waiting to happen,
+// so a conflict always counts as drift.
+func MarkConflicts(results []common.DriftResult, conflicts []common.StateConflict) {
+	origins := make(map[string][]string, len(conflicts))
+	for _, conflict := range conflicts {
+		origins[conflict.InstanceID] = conflict.Origins
+	}
+
+	for i := range results {
+		managedBy, ok := origins[results[i].InstanceID]
+		if !ok || results[i].ObservedOnly {
+			continue
+		}
+		results[i].Differences["terraform_state"] = common.FieldDiff{
+			AWS:       "exists",
+			Terraform: fmt.Sprintf("managed by %d states: %s", len(managedBy), strings.Join(managedBy, ", ")),
+		}
+		results[i].DriftDetected = true
+	}
+}
+
+// ComparePlan predicts drift from a saved Terraform plan by comparing the values
+// each planned change will apply against live AWS.
+//
+// Only no-op and update changes are compared: replaced and deleted instances are
+// going away. A field is reported when live AWS differs from the planned value,
+// unless the plan itself changes that field and live AWS still holds the value the
+// plan was made against (that difference is exactly what apply will fix).
+// For no-op changes every reported field is drift the plan does not know about.
+// An instance that no longer exists in AWS is reported as missing_in_aws, so every
+// change passed in must be for an instance that was looked up.
+func ComparePlan(awsInstances []*common.EC2Instance, changes []common.PlannedChange, filter map[string]bool) []common.DriftResult {
+	awsMap := make(map[string]*common.EC2Instance, len(awsInstances))
+	for _, awsInst := range awsInstances {
+		awsMap[awsInst.InstanceID] = awsInst
+	}
+
+	results := make([]common.DriftResult, 0, len(changes))
+	for _, change := range changes {
+		if change.Action != common.PlanActionNoOp && change.Action != common.PlanActionUpdate {
+			continue
+		}
+		awsInst, ok := awsMap[change.Before.InstanceID]
+		if !ok || awsInst.State == common.InstanceStateTerminated {
+			// the plan was made against an instance that no longer exists
+			result := missingInAWS(change.Before, awsInst)
+			result.PlannedAction = change.Action
+			results = append(results, result)
+			continue
+		}
+
+		result := compareInstances(awsInst, change.After, filter)
+		result.PlannedAction = change.Action
+
+		planned := compareInstances(change.Before, change.After, filter)
+		sinceRefresh := compareInstances(awsInst, change.Before, filter)
+		for field := range result.Differences {
+			_, changedByPlan := planned.Differences[field]
+			_, changedSincePlan := sinceRefresh.Differences[field]
+			if changedByPlan && !changedSincePlan {
+				delete(result.Differences, field)
+			}
+		}
+
+		result.DriftDetected = len(result.Differences) > 0
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// CompareObservedInstances compares AWS instances against the observed-only
+// (data source) entries of the Terraform state.
+//
+// Only AWS instances that are looked up by a data source are compared, and every
+// result is marked ObservedOnly: differences are informational since Terraform
+// does not manage these instances from this state.
+func CompareObservedInstances(awsInstances []*common.EC2Instance, tfInstances []*common.EC2Instance, filter map[string]bool) []common.DriftResult {
+	awsMap := make(map[string]*common.EC2Instance, len(awsInstances))
+	for _, awsInst := range awsInstances {
+		awsMap[awsInst.InstanceID] = awsInst
+	}
+
+	results := make([]common.DriftResult, 0)
+	for _, tfInst := range tfInstances {
+		if !tfInst.ObservedOnly {
+			continue
+		}
+		awsInst, ok := awsMap[tfInst.InstanceID]
+		if !ok {
+			continue
+		}
+
+		result := compareInstances(awsInst, tfInst, filter)
+		result.ObservedOnly = true
+		results = append(results, result)
+	}
+
+	return results
+}
+
 // PrintDriftReport outputs a drift result to standard output in either
 // human-readable or JSON format, depending on the asJSON flag.
 //
@@ -244,9 +688,42 @@ func PrintDriftReport(result common.DriftResult, asJSON bool) {
 	}
 
 	header := fmt.Sprintf("Drift Report for Instance ID: %s", result.InstanceID)
+	if result.ObservedOnly {
+		header = fmt.Sprintf("Observed-only Report for Instance ID: %s", result.InstanceID)
+	}
 	fmt.Println(strings.Repeat("=", len(header)))
 	fmt.Println(header)
 	fmt.Println(strings.Repeat("=", len(header)))
+	if result.Address != "" {
+		fmt.Printf("Terraform address: %s\n", result.Address)
+	}
+	if result.AccountID != "" {
+		fmt.Printf("AWS account: %s\n", result.AccountID)
+	}
+	if result.Origin != "" {
+		fmt.Printf("State: %s\n", result.Origin)
+	}
+	if result.PlannedAction != "" {
+		fmt.Printf("Planned action: %s\n", result.PlannedAction)
+	}
+	if result.ObservedOnly {
+		fmt.Println("ℹ️  Read through a data source - this state does not manage the instance, differences are informational.")
+	}
+	if result.Kind == common.KindMissingInAWS {
+		fmt.Println("💀 Missing in AWS - the instance was deleted or terminated but is still in the Terraform state.")
+	}
+	if len(result.Unknown) > 0 {
+		// a plan leaves values unknown until apply, the configuration when it can't resolve them statically
+		if result.PlannedAction != "" {
+			fmt.Printf("❔ Not known until apply, not compared: %s\n", strings.Join(result.Unknown, ", "))
+		} else {
+			fmt.Printf("❔ Not resolvable from configuration, not compared: %s\n", strings.Join(result.Unknown, ", "))
+		}
+	}
+
+	if result.VolumeSettingsUnknown {
+		fmt.Println("❔ EBS volume settings could not be read from AWS, not compared: size, type, iops, throughput, encryption")
+	}
 
 	if !result.DriftDetected {
 		fmt.Println("✅ No drift detected.")
@@ -260,6 +737,30 @@ func PrintDriftReport(result common.DriftResult, asJSON bool) {
 		fmt.Printf("- %s:\n", field)
 		fmt.Printf("    AWS:       %v\n", diff.AWS)
 		fmt.Printf("    Terraform: %v\n", diff.Terraform)
+		if diff.Category == common.CategoryUnknownInConfig {
+			fmt.Println("    Config:    (not resolvable)")
+			fmt.Printf("    Category:  %s\n", diff.Category)
+		} else if diff.Category != "" {
+			fmt.Printf("    Config:    %v\n", diff.Config)
+			fmt.Printf("    Category:  %s\n", diff.Category)
+		}
+		if diff.Sensitive {
+			fmt.Println("    🔒 Sensitive in Terraform - values are masked")
+		}
+		for _, key := range sortedKeys(diff.TagChanges) {
+			fmt.Printf("    %s: %s\n", key, strings.ReplaceAll(string(diff.TagChanges[key]), "_", " "))
+		}
 		fmt.Println()
 	}
 }
+
+// sortedKeys returns the keys of m in order, so reports print deterministically.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
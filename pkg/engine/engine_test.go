@@ -182,6 +182,41 @@ func TestCompareInstances(t *testing.T) {
 	}
 }
 
+func TestCompareInstances_CarriesAddress(t *testing.T) {
+	awsInst := &common.EC2Instance{InstanceID: "i-1", InstanceType: "t3.micro"}
+	tfInst := &common.EC2Instance{InstanceID: "i-1", Address: "module.web.aws_instance.app[2]", InstanceType: "t3.small"}
+
+	got := compareInstances(awsInst, tfInst, map[string]bool{"instance_type": true})
+
+	assert.True(t, got.DriftDetected)
+	assert.Equal(t, "module.web.aws_instance.app[2]", got.Address)
+}
+
+func TestCompareInstances_CarriesAccountID(t *testing.T) {
+	awsInst := &common.EC2Instance{InstanceID: "i-1", AccountID: "123456789012"}
+	tfInst := &common.EC2Instance{InstanceID: "i-1"}
+
+	assert.Equal(t, "123456789012", compareInstances(awsInst, tfInst, nil).AccountID)
+
+	unmanaged := CompareAllInstances(context.Background(), []*common.EC2Instance{awsInst}, nil, nil)
+	assert.Equal(t, "123456789012", unmanaged[0].AccountID)
+}
+
+func TestCompareInstances_UnknownNotReportedAsDrift(t *testing.T) {
+	awsInst := &common.EC2Instance{InstanceID: "i-1", InstanceType: "t3.micro", SubnetID: "subnet-live", Architecture: "x86_64"}
+	tfInst := &common.EC2Instance{
+		InstanceID:   "i-1",
+		InstanceType: "t3.micro",
+		Unknown:      map[string]bool{"subnet_id": true, "architecture": true, "tags": true},
+	}
+
+	got := compareInstances(awsInst, tfInst, map[string]bool{"instance_type": true, "subnet_id": true, "architecture": true})
+
+	assert.False(t, got.DriftDetected)
+	assert.Empty(t, got.Differences)
+	assert.Equal(t, []string{"architecture", "subnet_id"}, got.Unknown)
+}
+
 func TestCompareAllInstances(t *testing.T) {
 	aws := []*common.EC2Instance{
 		{InstanceID: "i-1", InstanceType: "t3.micro"},
@@ -303,6 +338,7 @@ func TestPrintDriftReport_Human_NoDrift(t *testing.T) {
 func TestPrintDriftReport_Human_WithDrift(t *testing.T) {
 	result := common.DriftResult{
 		InstanceID:    "i-456",
+		Address:       "module.web.aws_instance.app[0]",
 		DriftDetected: true,
 		Differences: map[string]common.FieldDiff{
 			"tags": {AWS: map[string]string{"Name": "A"}, Terraform: map[string]string{"Name": "B"}},
@@ -314,6 +350,7 @@ func TestPrintDriftReport_Human_WithDrift(t *testing.T) {
 	})
 
 	if !strings.Contains(output, "Drift Report for Instance ID: i-456") ||
+		!strings.Contains(output, "Terraform address: module.web.aws_instance.app[0]") ||
 		!strings.Contains(output, "tags:") ||
 		!strings.Contains(output, "AWS:") {
 		t.Errorf("expected drift fields in output, got:\n%s", output)
@@ -363,3 +400,367 @@ func captureOutput(f func()) string {
 	_, _ = buf.ReadFrom(r)
 	return buf.String()
 }
+
+func TestCompareObservedInstances(t *testing.T) {
+	aws := []*common.EC2Instance{
+		{InstanceID: "i-managed", InstanceType: "t3.micro"},
+		{InstanceID: "i-shared", InstanceType: "t3.large"},
+	}
+	tf := []*common.EC2Instance{
+		{InstanceID: "i-managed", InstanceType: "t3.micro"},
+		{InstanceID: "i-shared", Address: "data.aws_instance.bastion", InstanceType: "t3.medium", ObservedOnly: true},
+	}
+	filter := map[string]bool{"instance_type": true}
+
+	// a data source must not be treated as managing the instance, nor the instance as missing from the state
+	managed := CompareAllInstances(context.Background(), aws, tf, filter)
+	assert.Len(t, managed, 1)
+	assert.Equal(t, "i-managed", managed[0].InstanceID)
+
+	observed := CompareObservedInstances(aws, tf, filter)
+	assert.Len(t, observed, 1)
+	assert.Equal(t, "i-shared", observed[0].InstanceID)
+	assert.True(t, observed[0].ObservedOnly)
+	assert.Equal(t, "t3.medium", observed[0].Differences["instance_type"].Terraform)
+}
+
+func TestCompareThreeWay(t *testing.T) {
+	aws := []*common.EC2Instance{
+		{InstanceID: "i-1", InstanceType: "t3.large", KeyName: "key-a", ImageID: "ami-2"},
+		{InstanceID: "i-2", InstanceType: "t3.micro"},
+	}
+	state := []*common.EC2Instance{
+		{InstanceID: "i-1", InstanceType: "t3.micro", KeyName: "key-a", ImageID: "ami-1"},
+		{InstanceID: "i-2", InstanceType: "t3.micro"},
+	}
+	config := []*common.EC2Instance{
+		{InstanceID: "i-1", InstanceType: "t3.micro", KeyName: "key-b", ImageID: "ami-3", Unknown: map[string]bool{"subnet_id": true}},
+	}
+	filter := map[string]bool{"instance_type": true, "key_name": true, "image_id": true, "subnet_id": true}
+
+	results := CompareThreeWay(context.Background(), aws, state, config, filter)
+	assert.Len(t, results, 2)
+
+	for _, r := range results {
+		switch r.InstanceID {
+		case "i-1":
+			assert.True(t, r.DriftDetected)
+			assert.Equal(t, common.FieldDiff{AWS: "t3.large", Terraform: "t3.micro", Config: "t3.micro", Category: common.CategoryChangedOutsideTerraform}, r.Differences["instance_type"])
+			assert.Equal(t, common.FieldDiff{AWS: "key-a", Terraform: "key-a", Config: "key-b", Category: common.CategoryPendingApply}, r.Differences["key_name"])
+			assert.Equal(t, common.FieldDiff{AWS: "ami-2", Terraform: "ami-1", Config: "ami-3", Category: common.CategoryBoth}, r.Differences["image_id"])
+			assert.Equal(t, []string{"subnet_id"}, r.Unknown)
+		case "i-2":
+			assert.True(t, r.DriftDetected)
+			assert.Equal(t, common.CategoryPendingApply, r.Differences["terraform_config"].Category)
+		default:
+			t.Errorf("unexpected instance ID: %s", r.InstanceID)
+		}
+	}
+}
+
+func TestCompareConfig(t *testing.T) {
+	aws := []*common.EC2Instance{
+		{InstanceID: "i-1", InstanceType: "t3.large"},
+		{InstanceID: "i-2", InstanceType: "t3.micro"},
+		{InstanceID: "i-3", State: common.InstanceStateTerminated},
+		{InstanceID: "i-4"},
+	}
+	state := []*common.EC2Instance{
+		{InstanceID: "i-1", InstanceType: "t3.large", Address: "aws_instance.web"},
+		{InstanceID: "i-2", InstanceType: "t3.micro", Address: "aws_instance.old", Origin: "prod.tfstate"},
+		{InstanceID: "i-3", Address: "aws_instance.gone"},
+		{InstanceID: "i-4", ObservedOnly: true},
+	}
+	config := []*common.EC2Instance{
+		{InstanceID: "i-1", InstanceType: "t3.micro", Address: "aws_instance.web"},
+	}
+	filter := map[string]bool{"instance_type": true}
+
+	results := CompareConfig(context.Background(), aws, state, config, filter)
+	assert.Len(t, results, 3)
+
+	for _, r := range results {
+		switch r.InstanceID {
+		case "i-1":
+			assert.Equal(t, common.FieldDiff{AWS: "t3.large", Terraform: "t3.micro"}, r.Differences["instance_type"])
+		case "i-2":
+			assert.True(t, r.DriftDetected)
+			assert.Equal(t, "aws_instance.old", r.Address)
+			assert.Equal(t, "prod.tfstate", r.Origin)
+			assert.Equal(t, common.FieldDiff{AWS: "exists", Terraform: "exists", Config: "missing", Category: common.CategoryPendingApply}, r.Differences["terraform_config"])
+			assert.NotContains(t, r.Differences, "terraform_state")
+		case "i-3":
+			assert.Equal(t, common.KindMissingInAWS, r.Kind)
+		default:
+			t.Errorf("unexpected instance ID: %s", r.InstanceID)
+		}
+	}
+}
+
+func TestComparePlan(t *testing.T) {
+	aws := []*common.EC2Instance{
+		{InstanceID: "i-noop", InstanceType: "t3.large"},
+		{InstanceID: "i-update", InstanceType: "t3.micro", KeyName: "manual"},
+		{InstanceID: "i-replace", InstanceType: "t3.micro"},
+	}
+	changes := []common.PlannedChange{
+		{
+			Action: common.PlanActionNoOp,
+			Before: &common.EC2Instance{InstanceID: "i-noop", InstanceType: "t3.micro"},
+			After:  &common.EC2Instance{InstanceID: "i-noop", InstanceType: "t3.micro"},
+		},
+		{
+			Action: common.PlanActionUpdate,
+			Before: &common.EC2Instance{InstanceID: "i-update", InstanceType: "t3.micro", KeyName: "deployer"},
+			After:  &common.EC2Instance{InstanceID: "i-update", InstanceType: "t3.large", KeyName: "deployer"},
+		},
+		{
+			Action: common.PlanActionReplace,
+			Before: &common.EC2Instance{InstanceID: "i-replace", InstanceType: "t3.micro"},
+			After:  &common.EC2Instance{InstanceID: "i-replace", InstanceType: "t3.large"},
+		},
+	}
+	filter := map[string]bool{"instance_type": true, "key_name": true}
+
+	results := ComparePlan(aws, changes, filter)
+	assert.Len(t, results, 2)
+
+	noop := results[0]
+	assert.Equal(t, "i-noop", noop.InstanceID)
+	assert.Equal(t, common.PlanActionNoOp, noop.PlannedAction)
+	assert.True(t, noop.DriftDetected)
+	assert.Equal(t, common.FieldDiff{AWS: "t3.large", Terraform: "t3.micro"}, noop.Differences["instance_type"])
+
+	// the planned instance type change is expected, the key name changed behind the plan's back
+	update := results[1]
+	assert.True(t, update.DriftDetected)
+	assert.NotContains(t, update.Differences, "instance_type")
+	assert.Equal(t, common.FieldDiff{AWS: "manual", Terraform: "deployer"}, update.Differences["key_name"])
+}
+
+func TestMarkConflicts(t *testing.T) {
+	results := []common.DriftResult{
+		{InstanceID: "i-1", Differences: map[string]common.FieldDiff{}},
+		{InstanceID: "i-2", Differences: map[string]common.FieldDiff{}},
+	}
+
+	MarkConflicts(results, []common.StateConflict{{InstanceID: "i-1", Origins: []string{"a.tfstate", "b.tfstate"}}})
+
+	assert.True(t, results[0].DriftDetected)
+	assert.Equal(t, "managed by 2 states: a.tfstate, b.tfstate", results[0].Differences["terraform_state"].Terraform)
+	assert.False(t, results[1].DriftDetected)
+}
+
+func TestCompareInstances_TagsAll(t *testing.T) {
+	awsInst := &common.EC2Instance{
+		InstanceID: "i-1",
+		Tags:       map[string]string{"Name": "web-old", "Team": "platform", "Manual": "yes"},
+	}
+	tfInst := &common.EC2Instance{
+		InstanceID: "i-1",
+		Tags:       map[string]string{"Name": "web"},
+		TagsAll:    map[string]string{"Name": "web", "Team": "platform", "Env": "prod", "CostCenter": "42"},
+	}
+	awsInst.Tags["CostCenter"] = "7"
+
+	result := compareInstances(awsInst, tfInst, map[string]bool{"tags": true})
+	assert.True(t, result.DriftDetected)
+	assert.Equal(t, map[string]common.TagChange{
+		"Name":       common.TagResourceChanged,
+		"Env":        common.TagDefaultMissing,
+		"CostCenter": common.TagDefaultChanged,
+		"Manual":     common.TagUnexpected,
+	}, result.Differences["tags"].TagChanges)
+
+	// default tags present live are not drift
+	awsInst.Tags = map[string]string{"Name": "web", "Team": "platform", "Env": "prod", "CostCenter": "42"}
+	result = compareInstances(awsInst, tfInst, map[string]bool{"tags": true})
+	assert.False(t, result.DriftDetected)
+}
+
+func TestCompareInstances_MasksSensitiveFields(t *testing.T) {
+	awsInst := &common.EC2Instance{InstanceID: "i-1", KeyName: "live-key", InstanceType: "t3.large"}
+	tfInst := &common.EC2Instance{InstanceID: "i-1", KeyName: "tf-key", InstanceType: "t3.micro", Sensitive: map[string]bool{"key_name": true}}
+
+	result := compareInstances(awsInst, tfInst, map[string]bool{"key_name": true, "instance_type": true})
+	assert.True(t, result.DriftDetected)
+
+	diff := result.Differences["key_name"]
+	assert.True(t, diff.Sensitive)
+	assert.Equal(t, common.MaskSensitive("live-key"), diff.AWS)
+	assert.Equal(t, common.MaskSensitive("tf-key"), diff.Terraform)
+	assert.NotEqual(t, diff.AWS, diff.Terraform)
+
+	assert.Equal(t, common.FieldDiff{AWS: "t3.large", Terraform: "t3.micro"}, result.Differences["instance_type"])
+
+	out, err := json.Marshal(result)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(out), "live-key")
+	assert.NotContains(t, string(out), "tf-key")
+}
+
+func TestCompareInstances_AttachedVolumeSettings(t *testing.T) {
+	awsInst := &common.EC2Instance{
+		InstanceID: "i-1",
+		BlockDeviceMappings: []common.BlockDeviceMapping{
+			{DeviceName: "/dev/xvda", VolumeID: "vol-1", VolumeSize: 8, VolumeType: "gp3", Iops: 3000, Throughput: 125, DeleteOnTermination: true},
+			{DeviceName: "/dev/sdf", VolumeID: "vol-2", VolumeSize: 100, VolumeType: "gp3", Iops: 3000, Throughput: 125, Encrypted: true},
+		},
+	}
+	tfInst := &common.EC2Instance{
+		InstanceID: "i-1",
+		BlockDeviceMappings: []common.BlockDeviceMapping{
+			{DeviceName: "/dev/xvda", VolumeID: "vol-1", VolumeSize: 8, VolumeType: "gp3", DeleteOnTermination: true},
+			{DeviceName: "/dev/sdf", VolumeID: "vol-2", Attached: true},
+		},
+	}
+
+	// neither the attached volume nor the settings the root device leaves unset are drift
+	result := compareInstances(awsInst, tfInst, map[string]bool{"block_device_mappings": true})
+	assert.False(t, result.DriftDetected)
+	assert.Empty(t, result.Differences)
+}
+
+func TestCompareInstances_BlockDeviceSettings(t *testing.T) {
+	awsInst := &common.EC2Instance{
+		InstanceID: "i-1",
+		BlockDeviceMappings: []common.BlockDeviceMapping{
+			{DeviceName: "/dev/xvda", VolumeID: "vol-1", VolumeSize: 50, VolumeType: "gp3", Iops: 3000, Throughput: 125},
+		},
+	}
+	tfInst := &common.EC2Instance{
+		InstanceID: "i-1",
+		BlockDeviceMappings: []common.BlockDeviceMapping{
+			{DeviceName: "/dev/xvda", VolumeID: "vol-1", VolumeSize: 20, VolumeType: "gp2", Iops: 100},
+		},
+	}
+
+	result := compareInstances(awsInst, tfInst, map[string]bool{"block_device_mappings": true})
+	assert.True(t, result.DriftDetected)
+	assert.Equal(t, map[string]common.FieldDiff{
+		"block_device_mappings[/dev/xvda].volume_size": {AWS: 50, Terraform: 20},
+		"block_device_mappings[/dev/xvda].volume_type": {AWS: "gp3", Terraform: "gp2"},
+		"block_device_mappings[/dev/xvda].iops":        {AWS: 3000, Terraform: 100},
+	}, result.Differences)
+
+	// live volume settings that could not be read are not compared
+	awsInst.VolumeSettingsUnknown = true
+	result = compareInstances(awsInst, tfInst, map[string]bool{"block_device_mappings": true})
+	assert.False(t, result.DriftDetected)
+	assert.True(t, result.VolumeSettingsUnknown)
+	awsInst.VolumeSettingsUnknown = false
+
+	// unknown block devices hide the per-device settings as well
+	tfInst.Unknown = map[string]bool{"block_device_mappings": true}
+	result = compareInstances(awsInst, tfInst, map[string]bool{"block_device_mappings": true})
+	assert.False(t, result.DriftDetected)
+
+	result = compareInstances(awsInst, tfInst, map[string]bool{"instance_type": true})
+	assert.False(t, result.DriftDetected)
+}
+
+func TestCompareAllInstances_Terminated(t *testing.T) {
+	aws := []*common.EC2Instance{
+		{InstanceID: "i-managed", AccountID: "123456789012", State: common.InstanceStateTerminated},
+		{InstanceID: "i-unmanaged", State: common.InstanceStateTerminated},
+	}
+	tf := []*common.EC2Instance{
+		{InstanceID: "i-managed", Address: "aws_instance.web", Origin: "prod.tfstate"},
+	}
+
+	results := CompareAllInstances(context.Background(), aws, tf, nil)
+	assert.Equal(t, []common.DriftResult{{
+		InstanceID:    "i-managed",
+		AccountID:     "123456789012",
+		Kind:          common.KindMissingInAWS,
+		Address:       "aws_instance.web",
+		Origin:        "prod.tfstate",
+		DriftDetected: true,
+		Differences: map[string]common.FieldDiff{
+			"instance": {AWS: common.InstanceStateTerminated, Terraform: "exists"},
+		},
+	}}, results)
+}
+
+func TestFindMissingInstances(t *testing.T) {
+	aws := []*common.EC2Instance{{InstanceID: "i-live"}}
+	tf := []*common.EC2Instance{
+		{InstanceID: "i-live", Address: "aws_instance.live"},
+		{InstanceID: "i-deleted", Address: "aws_instance.deleted", AccountID: "123456789012"},
+		{InstanceID: "i-lookup", ObservedOnly: true},
+	}
+
+	results := FindMissingInstances([]string{"i-live", "i-deleted", "i-deleted", "i-lookup", "i-unknown"}, aws, tf)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "i-deleted", results[0].InstanceID)
+	assert.Equal(t, "123456789012", results[0].AccountID)
+	assert.Equal(t, common.KindMissingInAWS, results[0].Kind)
+	assert.Equal(t, "aws_instance.deleted", results[0].Address)
+	assert.True(t, results[0].DriftDetected)
+	assert.Equal(t, common.FieldDiff{AWS: "missing", Terraform: "exists"}, results[0].Differences["instance"])
+}
+
+func TestComparePlan_MissingInAWS(t *testing.T) {
+	aws := []*common.EC2Instance{{InstanceID: "i-terminated", State: common.InstanceStateTerminated}}
+	changes := []common.PlannedChange{
+		{Action: common.PlanActionNoOp, Before: &common.EC2Instance{InstanceID: "i-deleted"}, After: &common.EC2Instance{InstanceID: "i-deleted"}},
+		{Action: common.PlanActionUpdate, Before: &common.EC2Instance{InstanceID: "i-terminated"}, After: &common.EC2Instance{InstanceID: "i-terminated"}},
+	}
+
+	results := ComparePlan(aws, changes, nil)
+	assert.Len(t, results, 2)
+	assert.Equal(t, common.KindMissingInAWS, results[0].Kind)
+	assert.Equal(t, "missing", results[0].Differences["instance"].AWS)
+	assert.Equal(t, common.KindMissingInAWS, results[1].Kind)
+	assert.Equal(t, common.PlanActionUpdate, results[1].PlannedAction)
+	assert.Equal(t, common.InstanceStateTerminated, results[1].Differences["instance"].AWS)
+}
+
+func TestPrintDriftReport_Human_MissingInAWS(t *testing.T) {
+	output := captureOutput(func() {
+		PrintDriftReport(missingInAWS(&common.EC2Instance{InstanceID: "i-deleted"}, nil), false)
+	})
+
+	assert.Contains(t, output, "Missing in AWS")
+	assert.Contains(t, output, "❌ Drift detected")
+}
+
+func TestCompareThreeWay_UnknownAndSensitiveConfig(t *testing.T) {
+	aws := []*common.EC2Instance{{InstanceID: "i-1", SubnetID: "subnet-live", KeyName: "key-a"}}
+	state := []*common.EC2Instance{{InstanceID: "i-1", SubnetID: "subnet-state", KeyName: "key-a"}}
+	config := []*common.EC2Instance{{
+		InstanceID: "i-1",
+		KeyName:    "key-b",
+		Unknown:    map[string]bool{"subnet_id": true},
+		Sensitive:  map[string]bool{"key_name": true},
+	}}
+	filter := map[string]bool{"subnet_id": true, "key_name": true}
+
+	results := CompareThreeWay(context.Background(), aws, state, config, filter)
+	assert.Len(t, results, 1)
+
+	// the configuration's subnet was never resolved: no config value, and no claim it matches the state
+	assert.Equal(t, common.FieldDiff{AWS: "subnet-live", Terraform: "subnet-state", Category: common.CategoryUnknownInConfig}, results[0].Differences["subnet_id"])
+
+	keyName := results[0].Differences["key_name"]
+	assert.Equal(t, common.CategoryPendingApply, keyName.Category)
+	assert.True(t, keyName.Sensitive)
+	assert.NotContains(t, fmt.Sprint(keyName.Config), "key-b")
+}
+
+func TestPrintDriftReport_Human_Unknown(t *testing.T) {
+	fromConfig := captureOutput(func() {
+		PrintDriftReport(common.DriftResult{InstanceID: "i-1", Unknown: []string{"subnet_id"}}, false)
+	})
+	assert.Contains(t, fromConfig, "Not resolvable from configuration, not compared: subnet_id")
+
+	fromPlan := captureOutput(func() {
+		PrintDriftReport(common.DriftResult{InstanceID: "i-1", PlannedAction: common.PlanActionUpdate, Unknown: []string{"public_ip"}}, false)
+	})
+	assert.Contains(t, fromPlan, "Not known until apply, not compared: public_ip")
+
+	volumes := captureOutput(func() {
+		PrintDriftReport(common.DriftResult{InstanceID: "i-1", VolumeSettingsUnknown: true}, false)
+	})
+	assert.Contains(t, volumes, "EBS volume settings could not be read from AWS, not compared")
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// FindUnmanagedInstances returns the live instances that no loaded state manages, most recently launched first.
+// Data sources only read an instance, so an instance referenced by nothing but a data source is still unmanaged.
+// Terminated instances are skipped: DescribeInstances keeps listing them for a while after they are gone.
+func FindUnmanagedInstances(awsInstances, stateInstances []*common.EC2Instance) []common.UnmanagedInstance {
+	managed := make(map[string]bool, len(stateInstances))
+	for _, inst := range stateInstances {
+		if !inst.ObservedOnly {
+			managed[inst.InstanceID] = true
+		}
+	}
+
+	unmanaged := make([]common.UnmanagedInstance, 0)
+	for _, inst := range awsInstances {
+		if managed[inst.InstanceID] || inst.State == common.InstanceStateTerminated {
+			continue
+		}
+		unmanaged = append(unmanaged, common.UnmanagedInstance{
+			InstanceID:       inst.InstanceID,
+			AccountID:        inst.AccountID,
+			InstanceType:     inst.InstanceType,
+			State:            inst.State,
+			AvailabilityZone: inst.AvailabilityZone,
+			VpcID:            inst.VpcID,
+			LaunchTime:       inst.LaunchTime,
+			Tags:             inst.Tags,
+		})
+	}
+
+	slices.SortStableFunc(unmanaged, func(a, b common.UnmanagedInstance) int {
+		if c := b.LaunchTime.Compare(a.LaunchTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.InstanceID, b.InstanceID)
+	})
+
+	return unmanaged
+}
+
+// PrintUnmanagedReport prints the instances found by FindUnmanagedInstances.
+// With asJSON, the list is also written to results/unmanaged_<timestamp>.json.
+func PrintUnmanagedReport(instances []common.UnmanagedInstance, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(instances); err != nil {
+			log.Printf("failed to encode unmanaged instances report: %v\n", err)
+		}
+
+		fileName := fmt.Sprintf("results/unmanaged_%d.json", time.Now().Unix())
+		f, err := os.Create(fileName)
+		if err != nil {
+			log.Printf("❌ failed to write unmanaged instances JSON to file: %v", err)
+			return
+		}
+		defer func(f *os.File) {
+			err = f.Close()
+			if err != nil {
+				return
+			}
+		}(f)
+
+		encFile := json.NewEncoder(f)
+		encFile.SetIndent("", "  ")
+		if err := encFile.Encode(instances); err != nil {
+			log.Printf("❌failed to encode to file: %v\n", err)
+		} else {
+			fmt.Printf("JSON unmanaged instances report written to: %s\n", fileName)
+		}
+
+		return
+	}
+
+	header := "Unmanaged Instances (not in any Terraform state)"
+	fmt.Println(strings.Repeat("=", len(header)))
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("=", len(header)))
+
+	if len(instances) == 0 {
+		fmt.Println("✅ Every live instance is managed by Terraform.")
+		return
+	}
+
+	fmt.Printf("❌ %d instance(s) are not managed by Terraform:\n", len(instances))
+	fmt.Println()
+	for _, inst := range instances {
+		fmt.Printf("- %s:\n", inst.InstanceID)
+		if inst.AccountID != "" {
+			fmt.Printf("    Account:  %s\n", inst.AccountID)
+		}
+		fmt.Printf("    Type:     %s\n", inst.InstanceType)
+		fmt.Printf("    State:    %s\n", inst.State)
+		if inst.AvailabilityZone != "" {
+			fmt.Printf("    Zone:     %s\n", inst.AvailabilityZone)
+		}
+		if !inst.LaunchTime.IsZero() {
+			fmt.Printf("    Launched: %s\n", inst.LaunchTime.UTC().Format(time.RFC3339))
+		}
+		tags := make([]string, 0, len(inst.Tags))
+		for _, key := range sortedKeys(inst.Tags) {
+			tags = append(tags, key+"="+inst.Tags[key])
+		}
+		if len(tags) == 0 {
+			tags = append(tags, "(none)")
+		}
+		fmt.Printf("    Tags:     %s\n", strings.Join(tags, ", "))
+		fmt.Println()
+	}
+}
@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+func TestFindUnmanagedInstances(t *testing.T) {
+	launched := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	live := []*common.EC2Instance{
+		{InstanceID: "i-managed", State: "running", LaunchTime: launched},
+		{InstanceID: "i-old", State: "stopped", LaunchTime: launched.Add(-time.Hour), Tags: map[string]string{"Owner": "bob"}},
+		{InstanceID: "i-new", State: "running", LaunchTime: launched.Add(time.Hour)},
+		{InstanceID: "i-lookup", State: "running", LaunchTime: launched},
+		{InstanceID: "i-gone", State: "terminated", LaunchTime: launched},
+	}
+	state := []*common.EC2Instance{
+		{InstanceID: "i-managed"},
+		{InstanceID: "i-lookup", ObservedOnly: true},
+	}
+
+	unmanaged := FindUnmanagedInstances(live, state)
+
+	ids := make([]string, 0, len(unmanaged))
+	for _, inst := range unmanaged {
+		ids = append(ids, inst.InstanceID)
+	}
+	assert.Equal(t, []string{"i-new", "i-lookup", "i-old"}, ids)
+	assert.Equal(t, map[string]string{"Owner": "bob"}, unmanaged[2].Tags)
+	assert.Equal(t, launched.Add(-time.Hour), unmanaged[2].LaunchTime)
+}
+
+func TestPrintUnmanagedReport(t *testing.T) {
+	output := captureOutput(func() {
+		PrintUnmanagedReport([]common.UnmanagedInstance{{
+			InstanceID:   "i-orphan",
+			InstanceType: "t3.micro",
+			State:        "running",
+			LaunchTime:   time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			Tags:         map[string]string{"Owner": "bob", "Name": "scratch"},
+		}}, false)
+	})
+
+	assert.Contains(t, output, "1 instance(s) are not managed by Terraform")
+	assert.Contains(t, output, "Launched: 2025-03-01T12:00:00Z")
+	assert.Contains(t, output, "Tags:     Name=scratch, Owner=bob")
+
+	output = captureOutput(func() {
+		PrintUnmanagedReport(nil, false)
+	})
+	assert.Contains(t, output, "Every live instance is managed by Terraform")
+}
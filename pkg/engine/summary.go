@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// Summarize counts the checked instances, the drifted ones and those missing in AWS, alongside the AWS API call counts.
+// Observed-only results are informational and not counted at all. Drifted counts every instance that fails the
+// run, so it includes the ones missing in AWS.
+func Summarize(results []common.DriftResult, api common.APICallStats) common.RunSummary {
+	summary := common.RunSummary{API: api}
+	for _, result := range results {
+		if result.ObservedOnly {
+			continue
+		}
+		summary.Instances++
+		if result.DriftDetected {
+			summary.Drifted++
+		}
+		if result.Kind == common.KindMissingInAWS {
+			summary.MissingInAWS++
+		}
+	}
+
+	return summary
+}
+
+// PrintRunSummary prints the summary of a run, as JSON when asJSON is set.
+// Throttled requests are listed apart from failed ones: they say AWS was busy, not that a lookup is wrong.
+func PrintRunSummary(summary common.RunSummary, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			log.Printf("failed to encode run summary: %v\n", err)
+		}
+		return
+	}
+
+	header := "Run Summary"
+	fmt.Println(strings.Repeat("=", len(header)))
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("=", len(header)))
+	fmt.Printf("Instances checked: %d\n", summary.Instances)
+	fmt.Printf("Drifted:           %d\n", summary.Drifted)
+	fmt.Printf("  missing in AWS:  %d\n", summary.MissingInAWS)
+	fmt.Printf("AWS requests:      %d (%d retried)\n", summary.API.Requests, summary.API.Retries)
+	fmt.Printf("Throttled:         %d\n", summary.API.Throttled)
+	if summary.API.ThrottleFailures > 0 {
+		fmt.Printf("⏳ %d request(s) were still throttled after every retry - try again later or lower --rate-limit.\n", summary.API.ThrottleFailures)
+	}
+	if summary.API.Failures > 0 {
+		fmt.Printf("❌ %d request(s) failed.\n", summary.API.Failures)
+	}
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+func TestSummarize(t *testing.T) {
+	results := []common.DriftResult{
+		{InstanceID: "i-clean"},
+		{InstanceID: "i-drifted", DriftDetected: true},
+		{InstanceID: "i-observed", DriftDetected: true, ObservedOnly: true},
+		{InstanceID: "i-gone", DriftDetected: true, Kind: common.KindMissingInAWS},
+	}
+	api := common.APICallStats{Requests: 7, Retries: 2, Throttled: 2}
+
+	summary := Summarize(results, api)
+	assert.Equal(t, common.RunSummary{Instances: 3, Drifted: 2, MissingInAWS: 1, API: api}, summary)
+}
+
+func TestPrintRunSummary(t *testing.T) {
+	output := captureOutput(func() {
+		PrintRunSummary(common.RunSummary{
+			Instances: 3,
+			Drifted:   1,
+			API:       common.APICallStats{Requests: 9, Retries: 4, Throttled: 5, ThrottleFailures: 1},
+		}, false)
+	})
+
+	assert.Contains(t, output, "Instances checked: 3")
+	assert.Contains(t, output, "AWS requests:      9 (4 retried)")
+	assert.Contains(t, output, "Throttled:         5")
+	assert.Contains(t, output, "1 request(s) were still throttled")
+	assert.NotContains(t, output, "failed")
+
+	output = captureOutput(func() {
+		PrintRunSummary(common.RunSummary{Instances: 1, API: common.APICallStats{Requests: 1}}, true)
+	})
+	assert.Contains(t, output, `"throttle_failures": 0`)
+}
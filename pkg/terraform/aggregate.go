@@ -0,0 +1,133 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// stateFileSuffix is the extension of state files picked up when walking a directory.
+const stateFileSuffix = ".tfstate"
+
+// LoadAll loads every location and merges the instances, recording the location
+// each instance came from in its Origin.
+//
+// File locations may be globs (states/*/terraform.tfstate) or directories, which
+// are walked for *.tfstate files - local workspaces under terraform.tfstate.d
+// included. An instance ID managed by more than one state is returned as a conflict.
+// States that are empty or hold no resources (e.g. a fresh workspace) are skipped with a
+// warning; common.ErrTerraformInstanceMissing is only returned when every state was skipped.
+func (p *stateParser) LoadAll(ctx context.Context, locations []string) ([]*common.EC2Instance, []common.StateConflict, error) {
+	log := p.logger.With().Str(common.LogStrMethod, "LoadAll").Logger()
+
+	var expanded []string
+	for _, location := range locations {
+		paths, err := expandLocation(location)
+		if err != nil {
+			log.Err(err).Str("location", location).Msg("failed to expand state location")
+			return nil, nil, err
+		}
+		expanded = append(expanded, paths...)
+	}
+
+	var instances []*common.EC2Instance
+	skipped := 0
+	for _, location := range expanded {
+		loaded, err := p.Load(ctx, location)
+		if errors.Is(err, common.ErrTerraformInstanceMissing) {
+			log.Warn().Str("location", location).Msg("state holds no resources, skipping")
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, inst := range loaded {
+			inst.Origin = location
+		}
+		instances = append(instances, loaded...)
+	}
+
+	if skipped > 0 && skipped == len(expanded) {
+		return nil, nil, common.ErrTerraformInstanceMissing
+	}
+
+	return instances, findConflicts(instances), nil
+}
+
+// expandLocation turns a file glob or directory into the state files it refers to.
+// Any other location is returned as is.
+func expandLocation(location string) ([]string, error) {
+	if schemeOf(location) != SchemeFile {
+		return []string{location}, nil
+	}
+
+	path := strings.TrimPrefix(location, "file://")
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil || len(matches) == 0 {
+			return nil, common.ErrNoStateFilesMatched
+		}
+		return matches, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		// missing files are reported by the file source
+		return []string{location}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// .terraform holds provider plugins and module copies, never state we own
+		if d.IsDir() && d.Name() == ".terraform" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), stateFileSuffix) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil || len(paths) == 0 {
+		return nil, common.ErrNoStateFilesMatched
+	}
+
+	return paths, nil
+}
+
+// findConflicts returns the managed instance IDs that appear in more than one state, in order of first appearance.
+// Data sources are ignored since looking up an instance owned elsewhere is their whole point.
+func findConflicts(instances []*common.EC2Instance) []common.StateConflict {
+	origins := make(map[string][]string)
+	var order []string
+	for _, inst := range instances {
+		if inst.ObservedOnly || inst.InstanceID == "" {
+			continue
+		}
+		seen := origins[inst.InstanceID]
+		if len(seen) == 0 {
+			order = append(order, inst.InstanceID)
+		}
+		if !slices.Contains(seen, inst.Origin) {
+			origins[inst.InstanceID] = append(seen, inst.Origin)
+		}
+	}
+
+	var conflicts []common.StateConflict
+	for _, id := range order {
+		if len(origins[id]) > 1 {
+			conflicts = append(conflicts, common.StateConflict{InstanceID: id, Origins: origins[id]})
+		}
+	}
+
+	return conflicts
+}
@@ -0,0 +1,104 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// writeStates writes the given state files, keyed by relative path, under a fresh temporary directory.
+func writeStates(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+func TestLoadAll(t *testing.T) {
+	dir := writeStates(t, map[string]string{
+		"network/terraform.tfstate":                         `{"resources":[{"type":"aws_instance","name":"nat","instances":[{"attributes":{"id":"i-nat"}}]}]}`,
+		"app/terraform.tfstate":                             `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-web"}}]}]}`,
+		"app/terraform.tfstate.d/staging/terraform.tfstate": `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-web"}}]}]}`,
+		"app/.terraform/terraform.tfstate":                  `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-backend"}}]}]}`,
+		"app/terraform.tfstate.backup":                      `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-old"}}]}]}`,
+	})
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	got, conflicts, err := p.LoadAll(context.Background(), []string{filepath.Join(dir, "app")})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, []common.StateConflict{{
+		InstanceID: "i-web",
+		Origins: []string{
+			filepath.Join(dir, "app", "terraform.tfstate"),
+			filepath.Join(dir, "app", "terraform.tfstate.d", "staging", "terraform.tfstate"),
+		},
+	}}, conflicts)
+
+	got, conflicts, err = p.LoadAll(context.Background(), []string{filepath.Join(dir, "*", "terraform.tfstate")})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Empty(t, conflicts)
+	for _, inst := range got {
+		assert.Contains(t, []string{
+			filepath.Join(dir, "app", "terraform.tfstate"),
+			filepath.Join(dir, "network", "terraform.tfstate"),
+		}, inst.Origin)
+	}
+}
+
+func TestLoadAll_SkipsEmptyStates(t *testing.T) {
+	dir := writeStates(t, map[string]string{
+		"app/terraform.tfstate":                           `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-web"}}]}]}`,
+		"app/terraform.tfstate.d/fresh/terraform.tfstate": `{"version":4,"resources":[]}`,
+		"app/terraform.tfstate.d/blank/terraform.tfstate": ``,
+	})
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	got, _, err := p.LoadAll(context.Background(), []string{filepath.Join(dir, "app")})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "i-web", got[0].InstanceID)
+
+	// nothing left once every state is skipped
+	_, _, err = p.LoadAll(context.Background(), []string{filepath.Join(dir, "app", "terraform.tfstate.d")})
+	assert.ErrorIs(t, err, common.ErrTerraformInstanceMissing)
+
+	// a corrupt state still fails the load
+	corrupt := writeStates(t, map[string]string{"a.tfstate": `{"version":4,"resources":[`, "b.tfstate": `{"version":4,"resources":[]}`})
+	_, _, err = p.LoadAll(context.Background(), []string{corrupt})
+	assert.ErrorIs(t, err, common.ErrInvalidStateFile)
+}
+
+func TestLoadAll_Errors(t *testing.T) {
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	_, _, err := p.LoadAll(context.Background(), []string{filepath.Join(t.TempDir(), "*.tfstate")})
+	assert.ErrorIs(t, err, common.ErrNoStateFilesMatched)
+
+	_, _, err = p.LoadAll(context.Background(), []string{t.TempDir()})
+	assert.ErrorIs(t, err, common.ErrNoStateFilesMatched)
+
+	_, _, err = p.LoadAll(context.Background(), []string{filepath.Join(t.TempDir(), "missing.tfstate")})
+	assert.ErrorIs(t, err, common.ErrStateFileNotProvided)
+}
+
+func TestFindConflicts_IgnoresDataSources(t *testing.T) {
+	conflicts := findConflicts([]*common.EC2Instance{
+		{InstanceID: "i-1", Origin: "a.tfstate"},
+		{InstanceID: "i-1", Origin: "b.tfstate", ObservedOnly: true},
+	})
+	assert.Empty(t, conflicts)
+}
@@ -0,0 +1,221 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// DefaultCloudAddress is the base URL of the hosted Terraform Cloud (HCP Terraform) API.
+const DefaultCloudAddress = "https://app.terraform.io"
+
+// CloudClient defines a facade for reading state from Terraform Cloud / Enterprise workspaces.
+//
+// It is also a StateSource and StreamSource for tfc://<organization>/<workspace> locations.
+type CloudClient interface {
+	StateSource
+	StreamSource
+	GetCurrentState(ctx context.Context, organization, workspace string) ([]byte, error)
+	OpenCurrentState(ctx context.Context, organization, workspace string) (io.ReadCloser, error)
+}
+
+type cloudClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	logger     zerolog.Logger
+}
+
+// cloudDocument is the subset of a JSON:API document returned by the workspaces and state-versions endpoints.
+type cloudDocument struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			HostedStateDownloadURL string `json:"hosted-state-download-url"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// NewCloudClient creates a Terraform Cloud / Enterprise API client.
+//
+// baseURL is the address of the TFC/TFE installation; when empty, DefaultCloudAddress is used.
+func NewCloudClient(_ context.Context, logger zerolog.Logger, baseURL, token string) CloudClient {
+	if baseURL == "" {
+		baseURL = DefaultCloudAddress
+	}
+
+	return &cloudClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		logger:     logger.With().Str(common.LogStrLayer, "terraform-cloud").Logger(),
+	}
+}
+
+// Fetch downloads the current state of the workspace referenced by a tfc://<organization>/<workspace> location.
+func (c *cloudClient) Fetch(ctx context.Context, location string) ([]byte, error) {
+	organization, workspace, err := parseCloudURL(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetCurrentState(ctx, organization, workspace)
+}
+
+// Open streams the current state of the workspace referenced by a tfc://<organization>/<workspace> location.
+func (c *cloudClient) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	organization, workspace, err := parseCloudURL(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.OpenCurrentState(ctx, organization, workspace)
+}
+
+// parseCloudURL splits a tfc://<organization>/<workspace> location into its parts.
+func parseCloudURL(location string) (organization, workspace string, err error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != SchemeTFC {
+		return "", "", common.ErrInvalidStateURL
+	}
+
+	workspace = strings.Trim(u.Path, "/")
+	if u.Host == "" || workspace == "" || strings.Contains(workspace, "/") {
+		return "", "", common.ErrInvalidStateURL
+	}
+
+	return u.Host, workspace, nil
+}
+
+// GetCurrentState downloads the raw state of the current state version of a workspace.
+func (c *cloudClient) GetCurrentState(ctx context.Context, organization, workspace string) ([]byte, error) {
+	body, err := c.OpenCurrentState(ctx, organization, workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(body)
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.logger.Err(err).Str(common.LogStrMethod, "GetCurrentState").Msg("failed to download state")
+		return nil, common.ErrCloudRequestFailure
+	}
+
+	return data, nil
+}
+
+// OpenCurrentState opens the raw state of the current state version of a workspace as a stream.
+// The caller must close it.
+//
+// It resolves the workspace ID from its name, looks up the current state version
+// and follows its hosted-state-download-url.
+func (c *cloudClient) OpenCurrentState(ctx context.Context, organization, workspace string) (io.ReadCloser, error) {
+	log := c.logger.With().
+		Str(common.LogStrMethod, "OpenCurrentState").
+		Str("organization", organization).
+		Str("workspace", workspace).
+		Logger()
+
+	if c.token == "" {
+		log.Error().Msg("no API token configured")
+		return nil, common.ErrCloudTokenNotProvided
+	}
+
+	var ws cloudDocument
+	wsPath := fmt.Sprintf("/api/v2/organizations/%s/workspaces/%s", url.PathEscape(organization), url.PathEscape(workspace))
+	if err := c.getJSON(ctx, c.baseURL+wsPath, &ws); err != nil {
+		log.Err(err).Msg("failed to look up workspace")
+		return nil, err
+	}
+
+	var sv cloudDocument
+	svPath := fmt.Sprintf("/api/v2/workspaces/%s/current-state-version", url.PathEscape(ws.Data.ID))
+	if err := c.getJSON(ctx, c.baseURL+svPath, &sv); err != nil {
+		log.Err(err).Msg("failed to look up current state version")
+		return nil, err
+	}
+
+	downloadURL := sv.Data.Attributes.HostedStateDownloadURL
+	if downloadURL == "" {
+		log.Error().Msg("current state version has no download URL")
+		return nil, common.ErrCloudRequestFailure
+	}
+
+	body, err := c.open(ctx, downloadURL)
+	if err != nil {
+		log.Err(err).Msg("failed to download state")
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// getJSON performs an authenticated GET request and decodes the JSON:API response into out.
+func (c *cloudClient) getJSON(ctx context.Context, endpoint string, out any) error {
+	data, err := c.get(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, out); err != nil {
+		return common.ErrCloudRequestFailure
+	}
+
+	return nil
+}
+
+// get performs an authenticated GET request and returns the response body.
+func (c *cloudClient) get(ctx context.Context, endpoint string) ([]byte, error) {
+	body, err := c.open(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(body)
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, common.ErrCloudRequestFailure
+	}
+
+	return data, nil
+}
+
+// open performs an authenticated GET request and returns the unread response body. The caller must close it.
+func (c *cloudClient) open(ctx context.Context, endpoint string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, common.ErrCloudRequestFailure
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, common.ErrCloudRequestFailure
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// TFC also answers 404 when the token is not allowed to see the resource
+		_ = resp.Body.Close()
+		return nil, common.ErrCloudWorkspaceNotFound
+	case resp.StatusCode != http.StatusOK:
+		_ = resp.Body.Close()
+		return nil, common.ErrCloudRequestFailure
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,97 @@
+package terraform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// newCloudServer starts a fake Terraform Cloud API serving a single workspace.
+func newCloudServer(t *testing.T, state string) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/acme/workspaces/prod", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":"ws-123","type":"workspaces"}}`))
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-123/current-state-version", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"id":"sv-1","attributes":{"hosted-state-download-url":"` + server.URL + `/state/sv-1"}}}`))
+	})
+	mux.HandleFunc("/state/sv-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(state))
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCloudClient_GetCurrentState(t *testing.T) {
+	state := `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-1"}}]}]}`
+	server := newCloudServer(t, state)
+
+	client := NewCloudClient(context.Background(), zerolog.Nop(), server.URL, "secret")
+	data, err := client.GetCurrentState(context.Background(), "acme", "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, state, string(data))
+
+	// the downloaded state must be usable by the regular parser
+	instances, err := parseStateData(zerolog.Nop(), data, false)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "i-1", instances[0].InstanceID)
+}
+
+func TestCloudClient_GetCurrentState_Errors(t *testing.T) {
+	server := newCloudServer(t, "{}")
+
+	tests := []struct {
+		name      string
+		token     string
+		workspace string
+		wantErr   error
+	}{
+		{"missing token", "", "prod", common.ErrCloudTokenNotProvided},
+		{"unknown workspace", "secret", "staging", common.ErrCloudWorkspaceNotFound},
+		{"bad token", "wrong", "prod", common.ErrCloudRequestFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewCloudClient(context.Background(), zerolog.Nop(), server.URL, tt.token)
+			_, err := client.GetCurrentState(context.Background(), "acme", tt.workspace)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCloudClient_Fetch(t *testing.T) {
+	server := newCloudServer(t, singleInstanceState)
+
+	client := NewCloudClient(context.Background(), zerolog.Nop(), server.URL, "secret")
+	p := NewParser(context.Background(), zerolog.Nop())
+	p.RegisterSource(SchemeTFC, client)
+
+	got, err := p.Load(context.Background(), "tfc://acme/prod")
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	_, err = p.Load(context.Background(), "tfc://acme/")
+	assert.ErrorIs(t, err, common.ErrInvalidStateURL)
+
+	// the parser streams the state, Fetch still hands out the whole document
+	data, err := client.Fetch(context.Background(), "tfc://acme/prod")
+	assert.NoError(t, err)
+	assert.Equal(t, singleInstanceState, string(data))
+}
@@ -0,0 +1,629 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/rs/zerolog"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"github.com/zclconf/go-cty/cty/gocty"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// ConfigParser defines a facade for reading the desired state from Terraform configuration (.tf) files.
+type ConfigParser interface {
+	LoadDir(dir string, varFiles ...string) ([]*common.EC2Instance, error)
+}
+
+type configParser struct {
+	logger zerolog.Logger
+}
+
+// NewConfigParser creates a ConfigParser.
+func NewConfigParser(_ context.Context, logger zerolog.Logger) ConfigParser {
+	return &configParser{
+		logger: logger.With().Str(common.LogStrLayer, "terraform-config").Logger(),
+	}
+}
+
+// LoadDir reads the .tf and .tf.json files of a root module directory and returns its aws_instance resources.
+//
+// Variables are resolved the way Terraform does it: declared defaults, then TF_VAR_* environment
+// variables, terraform.tfvars, *.auto.tfvars and finally varFiles, in that order.
+// Attributes that cannot be resolved statically (e.g. references to other resources) are
+// recorded in EC2Instance.Unknown instead of being given a value.
+func (p *configParser) LoadDir(dir string, varFiles ...string) ([]*common.EC2Instance, error) {
+	log := p.logger.With().
+		Str(common.LogStrMethod, "LoadDir - parseConfigDir").
+		Str("dir", dir).
+		Logger()
+	return parseConfigDir(log, dir, varFiles)
+}
+
+// AttachInstanceIDs fills in the InstanceID of configuration instances from the state entry with the
+// same address, since configuration alone cannot know which live instance a block created.
+// It returns the configuration instances that have no counterpart in the state (not applied yet).
+func AttachInstanceIDs(config, state []*common.EC2Instance) []*common.EC2Instance {
+	byAddress := make(map[string]string, len(state))
+	for _, inst := range state {
+		byAddress[inst.Address] = inst.InstanceID
+	}
+
+	var unmatched []*common.EC2Instance
+	for _, inst := range config {
+		id, ok := byAddress[inst.Address]
+		if !ok {
+			unmatched = append(unmatched, inst)
+			continue
+		}
+		inst.InstanceID = id
+	}
+
+	return unmatched
+}
+
+var (
+	configSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "locals"},
+			{Type: "provider", LabelNames: []string{"name"}},
+			{Type: "resource", LabelNames: []string{"type", "name"}},
+		},
+	}
+
+	providerSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "alias"}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "default_tags"}},
+	}
+
+	defaultTagsSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "tags"}},
+	}
+
+	variableSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "default"}},
+	}
+
+	instanceSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "count"},
+			{Name: "for_each"},
+			{Name: "ami"},
+			{Name: "instance_type"},
+			{Name: "key_name"},
+			{Name: "subnet_id"},
+			{Name: "vpc_security_group_ids"},
+			{Name: "tags"},
+			{Name: "iam_instance_profile"},
+			{Name: "monitoring"},
+			{Name: "availability_zone"},
+			{Name: "private_ip"},
+			{Name: "provider"},
+		},
+	}
+
+	// computedAttributes are only known once AWS has created the instance.
+	computedAttributes = []string{"architecture", "virtualization_type", "vpc_id", "block_device_mappings"}
+
+	// optionalComputedAttributes are chosen by AWS (or a launch template) when the configuration leaves them out.
+	optionalComputedAttributes = map[string]string{
+		"ami":                    "image_id",
+		"instance_type":          "instance_type",
+		"subnet_id":              "subnet_id",
+		"vpc_security_group_ids": "security_groups",
+	}
+
+	// configFunctions is the subset of Terraform's built-in functions that can be evaluated without providers.
+	configFunctions = map[string]function.Function{
+		"coalesce":   stdlib.CoalesceFunc,
+		"concat":     stdlib.ConcatFunc,
+		"format":     stdlib.FormatFunc,
+		"join":       stdlib.JoinFunc,
+		"length":     stdlib.LengthFunc,
+		"lookup":     stdlib.LookupFunc,
+		"lower":      stdlib.LowerFunc,
+		"merge":      stdlib.MergeFunc,
+		"replace":    stdlib.ReplaceFunc,
+		"split":      stdlib.SplitFunc,
+		"title":      stdlib.TitleFunc,
+		"tolist":     makeToFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":      makeToFunc(cty.Map(cty.DynamicPseudoType)),
+		"toset":      makeToFunc(cty.Set(cty.DynamicPseudoType)),
+		"tostring":   makeToFunc(cty.String),
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"upper":      stdlib.UpperFunc,
+		"element":    stdlib.ElementFunc,
+		"keys":       stdlib.KeysFunc,
+		"values":     stdlib.ValuesFunc,
+		"distinct":   stdlib.DistinctFunc,
+		"flatten":    stdlib.FlattenFunc,
+		"jsonencode": stdlib.JSONEncodeFunc,
+	}
+)
+
+// configFiles holds the parts of a root module needed to evaluate aws_instance blocks.
+type configFiles struct {
+	variables map[string]hcl.Expression // default value per declared variable, nil when there is none
+	locals    map[string]hcl.Expression
+	instances []*hcl.Block
+	// defaultTags holds the default_tags expression per aws provider alias ("" for the default provider),
+	// nil when the provider sets none
+	defaultTags map[string]hcl.Expression
+}
+
+// defaultTags are the resolved default_tags of an aws provider configuration.
+type defaultTags struct {
+	tags    map[string]string
+	unknown bool // default_tags is set but could not be resolved
+}
+
+// parseConfigDir parses the Terraform configuration in dir and extracts EC2Instance values.
+func parseConfigDir(log zerolog.Logger, dir string, varFiles []string) ([]*common.EC2Instance, error) {
+	tfFiles, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		log.Err(err).Msg("failed to list configuration files")
+		return nil, common.ErrInvalidConfig
+	}
+	jsonFiles, _ := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	tfFiles = append(tfFiles, jsonFiles...)
+	if len(tfFiles) == 0 {
+		log.Error().Msg("no .tf files found")
+		return nil, common.ErrNoConfigFiles
+	}
+	sort.Strings(tfFiles)
+
+	parser := hclparse.NewParser()
+	cfg := &configFiles{
+		variables:   make(map[string]hcl.Expression),
+		locals:      make(map[string]hcl.Expression),
+		defaultTags: make(map[string]hcl.Expression),
+	}
+
+	for _, path := range tfFiles {
+		file, diags := parseFile(parser, path)
+		if diags.HasErrors() {
+			log.Error().Str("file", path).Msg(diags.Error())
+			return nil, common.ErrInvalidConfig
+		}
+
+		content, _, diags := file.Body.PartialContent(configSchema)
+		if diags.HasErrors() {
+			log.Error().Str("file", path).Msg(diags.Error())
+			return nil, common.ErrInvalidConfig
+		}
+
+		for _, block := range content.Blocks {
+			switch block.Type {
+			case "variable":
+				varContent, _, _ := block.Body.PartialContent(variableSchema)
+				cfg.variables[block.Labels[0]] = nil
+				if attr, ok := varContent.Attributes["default"]; ok {
+					cfg.variables[block.Labels[0]] = attr.Expr
+				}
+			case "locals":
+				attrs, _ := block.Body.JustAttributes()
+				for name, attr := range attrs {
+					cfg.locals[name] = attr.Expr
+				}
+			case "provider":
+				if block.Labels[0] == "aws" {
+					alias, expr := parseProvider(log, block)
+					cfg.defaultTags[alias] = expr
+				}
+			case "resource":
+				if block.Labels[0] == "aws_instance" {
+					cfg.instances = append(cfg.instances, block)
+				}
+			}
+		}
+	}
+
+	vars, err := resolveVariables(log, parser, dir, cfg.variables, varFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":  cty.ObjectVal(vars),
+			"path": cty.ObjectVal(map[string]cty.Value{"module": cty.StringVal(dir), "root": cty.StringVal(dir)}),
+		},
+		Functions: configFunctions,
+	}
+	ctx.Variables["local"] = cty.ObjectVal(resolveLocals(ctx, cfg.locals))
+	providers := resolveDefaultTags(ctx, cfg.defaultTags)
+
+	var instances []*common.EC2Instance
+	for _, block := range cfg.instances {
+		instances = append(instances, expandInstance(log, ctx, block, providers)...)
+	}
+
+	return instances, nil
+}
+
+// parseProvider returns the alias of an aws provider block and its default_tags expression, if any.
+func parseProvider(log zerolog.Logger, block *hcl.Block) (string, hcl.Expression) {
+	content, _, diags := block.Body.PartialContent(providerSchema)
+	if diags.HasErrors() {
+		log.Warn().Str("provider", block.Labels[0]).Msg(diags.Error())
+	}
+
+	var alias string
+	if attr, ok := content.Attributes["alias"]; ok {
+		if v, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+			alias, _ = ctyToString(v)
+		}
+	}
+
+	for _, dt := range content.Blocks {
+		dtContent, _, _ := dt.Body.PartialContent(defaultTagsSchema)
+		if attr, ok := dtContent.Attributes["tags"]; ok {
+			return alias, attr.Expr
+		}
+	}
+	return alias, nil
+}
+
+// resolveDefaultTags evaluates the default_tags of every aws provider configuration that sets them.
+func resolveDefaultTags(ctx *hcl.EvalContext, exprs map[string]hcl.Expression) map[string]*defaultTags {
+	providers := make(map[string]*defaultTags, len(exprs))
+	for alias, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+		v, diags := expr.Value(ctx)
+		if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() || !v.CanIterateElements() {
+			providers[alias] = &defaultTags{unknown: true}
+			continue
+		}
+		dt := &defaultTags{tags: make(map[string]string)}
+		for it := v.ElementIterator(); it.Next(); {
+			key, val := it.Element()
+			k, _ := ctyToString(key)
+			if s, ok := ctyToString(val); ok {
+				dt.tags[k] = s
+			}
+		}
+		providers[alias] = dt
+	}
+	return providers
+}
+
+// providerAlias returns the aws provider alias an aws_instance selects with its provider meta-argument.
+func providerAlias(attrs hcl.Attributes) string {
+	attr, ok := attrs["provider"]
+	if !ok {
+		return ""
+	}
+	traversal, diags := hcl.AbsTraversalForExpr(attr.Expr)
+	if diags.HasErrors() || len(traversal) < 2 {
+		return ""
+	}
+	if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+		return step.Name
+	}
+	return ""
+}
+
+// parseFile parses a native syntax or JSON syntax HCL file.
+func parseFile(parser *hclparse.Parser, path string) (*hcl.File, hcl.Diagnostics) {
+	if strings.HasSuffix(path, ".json") {
+		return parser.ParseJSONFile(path)
+	}
+
+	return parser.ParseHCLFile(path)
+}
+
+// resolveVariables returns the value of each declared variable. Variables without any value are unknown.
+func resolveVariables(log zerolog.Logger, parser *hclparse.Parser, dir string, declared map[string]hcl.Expression, varFiles []string) (map[string]cty.Value, error) {
+	vars := make(map[string]cty.Value, len(declared))
+	for name, expr := range declared {
+		vars[name] = cty.DynamicVal
+		if expr == nil {
+			continue
+		}
+		if v, diags := expr.Value(nil); !diags.HasErrors() {
+			vars[name] = v
+		}
+	}
+
+	for name := range declared {
+		if raw, ok := os.LookupEnv("TF_VAR_" + name); ok {
+			vars[name] = cty.StringVal(raw)
+		}
+	}
+
+	files := []string{filepath.Join(dir, "terraform.tfvars"), filepath.Join(dir, "terraform.tfvars.json")}
+	autoFiles, _ := filepath.Glob(filepath.Join(dir, "*.auto.tfvars"))
+	autoJSONFiles, _ := filepath.Glob(filepath.Join(dir, "*.auto.tfvars.json"))
+	autoFiles = append(autoFiles, autoJSONFiles...)
+	sort.Strings(autoFiles)
+	files = append(files, autoFiles...)
+
+	for i, path := range append(files, varFiles...) {
+		explicit := i >= len(files)
+		if _, err := os.Stat(path); err != nil {
+			if explicit {
+				log.Err(err).Str("file", path).Msg("variable file not found")
+				return nil, common.ErrInvalidConfig
+			}
+			continue
+		}
+
+		file, diags := parseFile(parser, path)
+		if diags.HasErrors() {
+			log.Error().Str("file", path).Msg(diags.Error())
+			return nil, common.ErrInvalidConfig
+		}
+
+		attrs, diags := file.Body.JustAttributes()
+		if diags.HasErrors() {
+			log.Error().Str("file", path).Msg(diags.Error())
+			return nil, common.ErrInvalidConfig
+		}
+
+		for name, attr := range attrs {
+			if _, ok := declared[name]; !ok {
+				log.Warn().Str("file", path).Str("variable", name).Msg("value for undeclared variable ignored")
+				continue
+			}
+			if v, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+				vars[name] = v
+			}
+		}
+	}
+
+	return vars, nil
+}
+
+// resolveLocals evaluates locals, which may refer to each other in any order, until no more progress is made.
+// Locals that still cannot be evaluated are unknown.
+func resolveLocals(ctx *hcl.EvalContext, exprs map[string]hcl.Expression) map[string]cty.Value {
+	resolved := make(map[string]cty.Value, len(exprs))
+	for progress := true; progress; {
+		progress = false
+		ctx.Variables["local"] = cty.ObjectVal(resolved)
+		for name, expr := range exprs {
+			if _, done := resolved[name]; done {
+				continue
+			}
+			if v, diags := expr.Value(ctx); !diags.HasErrors() {
+				resolved[name] = v
+				progress = true
+			}
+		}
+	}
+
+	for name := range exprs {
+		if _, done := resolved[name]; !done {
+			resolved[name] = cty.DynamicVal
+		}
+	}
+
+	return resolved
+}
+
+// expandInstance evaluates an aws_instance block, producing one EC2Instance per count index or for_each key.
+func expandInstance(log zerolog.Logger, ctx *hcl.EvalContext, block *hcl.Block, providers map[string]*defaultTags) []*common.EC2Instance {
+	content, _, diags := block.Body.PartialContent(instanceSchema)
+	if diags.HasErrors() {
+		log.Warn().Str("resource", block.Labels[1]).Msg(diags.Error())
+	}
+
+	address := common.ResourceAddress("", "managed", block.Labels[0], block.Labels[1], nil)
+	defaults := providers[providerAlias(content.Attributes)]
+
+	if attr, ok := content.Attributes["count"]; ok {
+		v, diags := attr.Expr.Value(ctx)
+		if count, ok := ctyToInt(v); !diags.HasErrors() && ok {
+			instances := make([]*common.EC2Instance, 0, count)
+			for i := 0; i < count; i++ {
+				child := ctx.NewChild()
+				child.Variables = map[string]cty.Value{"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(i))})}
+				instances = append(instances, buildInstance(child, content.Attributes, common.ResourceAddress("", "managed", block.Labels[0], block.Labels[1], float64(i)), defaults))
+			}
+			return instances
+		}
+
+		log.Warn().Str("resource", address).Msg("count could not be resolved - treating resource as a single unknown instance")
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{"count": cty.ObjectVal(map[string]cty.Value{"index": cty.UnknownVal(cty.Number)})}
+		return []*common.EC2Instance{buildInstance(child, content.Attributes, address, defaults)}
+	}
+
+	if attr, ok := content.Attributes["for_each"]; ok {
+		v, diags := attr.Expr.Value(ctx)
+		if !diags.HasErrors() && v.IsWhollyKnown() && !v.IsNull() && v.CanIterateElements() {
+			var instances []*common.EC2Instance
+			for it := v.ElementIterator(); it.Next(); {
+				key, val := it.Element()
+				if v.Type().IsSetType() {
+					key = val
+				}
+				keyStr, ok := ctyToString(key)
+				if !ok {
+					continue
+				}
+				child := ctx.NewChild()
+				child.Variables = map[string]cty.Value{"each": cty.ObjectVal(map[string]cty.Value{"key": cty.StringVal(keyStr), "value": val})}
+				instances = append(instances, buildInstance(child, content.Attributes, common.ResourceAddress("", "managed", block.Labels[0], block.Labels[1], keyStr), defaults))
+			}
+			return instances
+		}
+
+		log.Warn().Str("resource", address).Msg("for_each could not be resolved - treating resource as a single unknown instance")
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{"each": cty.ObjectVal(map[string]cty.Value{"key": cty.UnknownVal(cty.String), "value": cty.DynamicVal})}
+		return []*common.EC2Instance{buildInstance(child, content.Attributes, address, defaults)}
+	}
+
+	return []*common.EC2Instance{buildInstance(ctx, content.Attributes, address, defaults)}
+}
+
+// buildInstance evaluates the attributes of a single aws_instance and maps them onto an EC2Instance.
+// defaults are the default_tags of the instance's provider, nil when it sets none.
+func buildInstance(ctx *hcl.EvalContext, attrs hcl.Attributes, address string, defaults *defaultTags) *common.EC2Instance {
+	inst := &common.EC2Instance{
+		Address: address,
+		Tags:    map[string]string{},
+		Unknown: map[string]bool{},
+	}
+	for _, field := range computedAttributes {
+		inst.Unknown[field] = true
+	}
+	for attrName, field := range optionalComputedAttributes {
+		if _, ok := attrs[attrName]; !ok {
+			inst.Unknown[field] = true
+		}
+	}
+
+	// eval returns the value of an attribute, or false when it is absent. unresolvable values are marked unknown.
+	eval := func(attrName, field string) (cty.Value, bool) {
+		attr, ok := attrs[attrName]
+		if !ok {
+			return cty.NilVal, false
+		}
+		v, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() || !v.IsWhollyKnown() {
+			if field != "" {
+				inst.Unknown[field] = true
+			}
+			return cty.NilVal, false
+		}
+		return v, true
+	}
+
+	setString := func(attrName, field string, dst *string) {
+		if v, ok := eval(attrName, field); ok {
+			if s, ok := ctyToString(v); ok {
+				*dst = s
+			} else if field != "" {
+				inst.Unknown[field] = true
+			}
+		}
+	}
+
+	setString("ami", "image_id", &inst.ImageID)
+	setString("instance_type", "instance_type", &inst.InstanceType)
+	setString("key_name", "key_name", &inst.KeyName)
+	setString("subnet_id", "subnet_id", &inst.SubnetID)
+	setString("iam_instance_profile", "iam_instance_profile", &inst.IamInstanceProfile)
+	setString("availability_zone", "", &inst.AvailabilityZone)
+	setString("private_ip", "", &inst.PrivateIPAddress)
+
+	if v, ok := eval("monitoring", "monitoring"); ok {
+		if b, ok := ctyToBool(v); ok {
+			inst.Monitoring = b
+		} else {
+			inst.Unknown["monitoring"] = true
+		}
+	}
+
+	if v, ok := eval("tags", "tags"); ok && !v.IsNull() && v.CanIterateElements() {
+		for it := v.ElementIterator(); it.Next(); {
+			key, val := it.Element()
+			k, _ := ctyToString(key)
+			if s, ok := ctyToString(val); ok {
+				inst.Tags[k] = s
+			}
+		}
+	}
+
+	// like the provider, merge default_tags under the resource's own tags into tags_all
+	if defaults != nil {
+		if defaults.unknown || inst.Unknown["tags"] {
+			inst.Unknown["tags"] = true
+		} else {
+			inst.TagsAll = make(map[string]string, len(defaults.tags)+len(inst.Tags))
+			for k, v := range defaults.tags {
+				inst.TagsAll[k] = v
+			}
+			for k, v := range inst.Tags {
+				inst.TagsAll[k] = v
+			}
+		}
+	}
+
+	if v, ok := eval("vpc_security_group_ids", "security_groups"); ok && !v.IsNull() && v.CanIterateElements() {
+		for it := v.ElementIterator(); it.Next(); {
+			_, val := it.Element()
+			if s, ok := ctyToString(val); ok {
+				inst.SecurityGroups = append(inst.SecurityGroups, s)
+			}
+		}
+	}
+
+	return inst
+}
+
+// ctyToString converts a known primitive value to its string form. Null converts to "".
+func ctyToString(v cty.Value) (string, bool) {
+	if v.IsNull() {
+		return "", true
+	}
+	if !v.IsKnown() {
+		return "", false
+	}
+
+	s, err := convert.Convert(v, cty.String)
+	if err != nil {
+		return "", false
+	}
+
+	return s.AsString(), true
+}
+
+// ctyToInt converts a known whole number (or numeric string) to an int.
+func ctyToInt(v cty.Value) (int, bool) {
+	n, err := convert.Convert(v, cty.Number)
+	if err != nil || n.IsNull() || !n.IsKnown() {
+		return 0, false
+	}
+
+	var i int
+	if err = gocty.FromCtyValue(n, &i); err != nil {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// ctyToBool converts a known bool (or "true"/"false" string) to a bool.
+func ctyToBool(v cty.Value) (bool, bool) {
+	b, err := convert.Convert(v, cty.Bool)
+	if err != nil || b.IsNull() || !b.IsKnown() {
+		return false, false
+	}
+
+	return b.True(), true
+}
+
+// makeToFunc returns a type conversion function such as Terraform's tostring or tolist.
+func makeToFunc(wantTy cty.Type) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "v", Type: cty.DynamicPseudoType, AllowNull: true, AllowDynamicType: true}},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			if !wantTy.HasDynamicTypes() {
+				return wantTy, nil
+			}
+			v, err := convert.Convert(args[0], wantTy)
+			if err != nil {
+				return cty.NilType, err
+			}
+			return v.Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return convert.Convert(args[0], retType)
+		},
+	})
+}
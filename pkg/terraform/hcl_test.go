@@ -0,0 +1,216 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// writeConfig writes the given files into a fresh temporary directory.
+func writeConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+func TestParseConfigDir(t *testing.T) {
+	dir := writeConfig(t, map[string]string{
+		"variables.tf": `
+variable "env" {
+  default = "dev"
+}
+
+variable "instance_type" {}
+
+variable "ami" {
+  default = "ami-default"
+}
+`,
+		"main.tf": `
+locals {
+  name = "${local.prefix}-web"
+  prefix = upper(var.env)
+  common_tags = {
+    Env  = var.env
+    Team = "platform"
+  }
+}
+
+resource "aws_instance" "web" {
+  ami                    = var.ami
+  instance_type          = var.instance_type
+  key_name               = "deployer"
+  subnet_id              = aws_subnet.main.id
+  vpc_security_group_ids = ["sg-1", "sg-2"]
+  monitoring             = true
+  tags                   = merge(local.common_tags, { Name = local.name })
+
+  root_block_device {
+    volume_size = 20
+  }
+}
+
+resource "aws_instance" "worker" {
+  count         = 2
+  ami           = "ami-worker"
+  instance_type = "t3.small"
+  subnet_id     = "subnet-1"
+  vpc_security_group_ids = ["sg-1"]
+  tags = {
+    Name = format("worker-%d", count.index)
+  }
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+`,
+		"terraform.tfvars": `instance_type = "t3.micro"`,
+		"prod.tfvars":      `env = "prod"`,
+	})
+
+	got, err := parseConfigDir(zerolog.Nop(), dir, []string{filepath.Join(dir, "prod.tfvars")})
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	web := got[0]
+	assert.Equal(t, "aws_instance.web", web.Address)
+	assert.Equal(t, "ami-default", web.ImageID)
+	assert.Equal(t, "t3.micro", web.InstanceType)
+	assert.Equal(t, "deployer", web.KeyName)
+	assert.True(t, web.Monitoring)
+	assert.Equal(t, []string{"sg-1", "sg-2"}, web.SecurityGroups)
+	assert.Equal(t, map[string]string{"Env": "prod", "Team": "platform", "Name": "PROD-web"}, web.Tags)
+	assert.True(t, web.Unknown["subnet_id"], "reference to another resource must be unknown")
+	assert.True(t, web.Unknown["architecture"], "computed attributes must be unknown")
+	assert.False(t, web.Unknown["instance_type"])
+
+	assert.Equal(t, "aws_instance.worker[0]", got[1].Address)
+	assert.Equal(t, "worker-0", got[1].Tags["Name"])
+	assert.Equal(t, "aws_instance.worker[1]", got[2].Address)
+	assert.Equal(t, "worker-1", got[2].Tags["Name"])
+	assert.Equal(t, "subnet-1", got[2].SubnetID)
+}
+
+func TestParseConfigDir_ForEachAndUnsetVariable(t *testing.T) {
+	dir := writeConfig(t, map[string]string{
+		"main.tf": `
+variable "ami" {}
+
+resource "aws_instance" "app" {
+  for_each      = toset(["blue", "green"])
+  ami           = var.ami
+  instance_type = "t3.micro"
+  tags = {
+    Color = each.key
+  }
+}
+`,
+	})
+
+	got, err := parseConfigDir(zerolog.Nop(), dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, `aws_instance.app["blue"]`, got[0].Address)
+	assert.Equal(t, "blue", got[0].Tags["Color"])
+	assert.True(t, got[0].Unknown["image_id"], "variable without value must be unknown")
+	assert.Equal(t, "t3.micro", got[0].InstanceType)
+}
+
+func TestParseConfigDir_DefaultTags(t *testing.T) {
+	dir := writeConfig(t, map[string]string{
+		"providers.tf": `
+variable "team" {}
+
+provider "aws" {
+  default_tags {
+    tags = {
+      Env  = "prod"
+      Name = "default"
+    }
+  }
+}
+
+provider "aws" {
+  alias = "shared"
+  default_tags {
+    tags = { Team = var.team }
+  }
+}
+
+provider "aws" {
+  alias = "plain"
+}
+`,
+		"main.tf": `
+resource "aws_instance" "web" {
+  tags = { Name = "web" }
+}
+
+resource "aws_instance" "shared" {
+  provider = aws.shared
+  tags     = { Name = "shared" }
+}
+
+resource "aws_instance" "plain" {
+  provider = aws.plain
+  tags     = { Name = "plain" }
+}
+`,
+	})
+
+	got, err := parseConfigDir(zerolog.Nop(), dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	byAddress := make(map[string]*common.EC2Instance, len(got))
+	for _, inst := range got {
+		byAddress[inst.Address] = inst
+	}
+	assert.Equal(t, map[string]string{"Env": "prod", "Name": "web"}, byAddress["aws_instance.web"].TagsAll)
+	assert.Equal(t, map[string]string{"Name": "web"}, byAddress["aws_instance.web"].Tags)
+	assert.Nil(t, byAddress["aws_instance.shared"].TagsAll)
+	assert.True(t, byAddress["aws_instance.shared"].Unknown["tags"], "unresolvable default_tags must make tags unknown")
+	assert.Nil(t, byAddress["aws_instance.plain"].TagsAll)
+	assert.False(t, byAddress["aws_instance.plain"].Unknown["tags"])
+}
+
+func TestParseConfigDir_Errors(t *testing.T) {
+	_, err := parseConfigDir(zerolog.Nop(), t.TempDir(), nil)
+	assert.ErrorIs(t, err, common.ErrNoConfigFiles)
+
+	dir := writeConfig(t, map[string]string{"main.tf": `resource "aws_instance" {`})
+	_, err = parseConfigDir(zerolog.Nop(), dir, nil)
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+
+	dir = writeConfig(t, map[string]string{"main.tf": `variable "a" {}`})
+	_, err = NewConfigParser(context.Background(), zerolog.Nop()).LoadDir(dir, filepath.Join(dir, "missing.tfvars"))
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+}
+
+func TestAttachInstanceIDs(t *testing.T) {
+	config := []*common.EC2Instance{
+		{Address: "aws_instance.web"},
+		{Address: "aws_instance.new"},
+	}
+	state := []*common.EC2Instance{
+		{InstanceID: "i-1", Address: "aws_instance.web"},
+	}
+
+	unmatched := AttachInstanceIDs(config, state)
+
+	assert.Equal(t, "i-1", config[0].InstanceID)
+	assert.Len(t, unmatched, 1)
+	assert.Equal(t, "aws_instance.new", unmatched[0].Address)
+}
@@ -0,0 +1,229 @@
+package terraform
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// legacyBoolAttributes are aws_instance attributes stored as "true"/"false" strings in a version 3 state.
+var legacyBoolAttributes = []string{"monitoring"}
+
+// legacyBlockBoolAttributes are the boolean attributes of block device blocks in a version 3 state.
+var legacyBlockBoolAttributes = []string{"encrypted", "delete_on_termination", "no_device"}
+
+// legacyBlockAttributes are the block device blocks of an aws_instance.
+var legacyBlockAttributes = []string{"root_block_device", "ebs_block_device", "ephemeral_block_device"}
+
+// parseLegacyState extracts EC2Instance values from a decoded version 3 state file.
+// Flatmap attributes are expanded back into nested values so they map exactly like a version 4 state.
+func parseLegacyState(log zerolog.Logger, state common.LegacyTerraformState, includeDataSources bool) ([]*common.EC2Instance, error) {
+	var (
+		instances   []*common.EC2Instance
+		attachments []volumeAttachment
+	)
+	found := false
+
+	for _, module := range state.Modules {
+		keys := make([]string, 0, len(module.Resources))
+		for key := range module.Resources {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		found = found || len(keys) > 0
+
+		for _, key := range keys {
+			res := module.Resources[key]
+			mode, resourceType, name, index := parseLegacyKey(key)
+			if res.Type != "" {
+				resourceType = res.Type
+			}
+			if res.Primary == nil {
+				continue
+			}
+			if resourceType == volumeAttachmentType && mode != "data" {
+				attachments = append(attachments, volumeAttachmentFrom(expandFlatmap(res.Primary.Attributes)))
+				continue
+			}
+			if resourceType != "aws_instance" {
+				continue
+			}
+
+			observedOnly := mode == "data"
+			if observedOnly && !includeDataSources {
+				continue
+			}
+
+			attrs := expandFlatmap(res.Primary.Attributes)
+			parseLegacyBools(attrs, legacyBoolAttributes)
+			for _, block := range legacyBlockAttributes {
+				devices, _ := attrs[block].([]interface{})
+				for _, device := range devices {
+					if m, ok := device.(map[string]interface{}); ok {
+						parseLegacyBools(m, legacyBlockBoolAttributes)
+					}
+				}
+			}
+			if _, ok := attrs["id"]; !ok {
+				attrs["id"] = res.Primary.ID
+			}
+
+			ec2Inst := instanceFromAttributes(attrs)
+			ec2Inst.Address = common.ResourceAddress(legacyModuleAddress(module.Path), mode, resourceType, name, index)
+			ec2Inst.ObservedOnly = observedOnly
+
+			instances = append(instances, ec2Inst)
+		}
+	}
+
+	if !found {
+		log.Error().Msg("no terraform resources found in legacy state file")
+		return nil, common.ErrTerraformInstanceMissing
+	}
+
+	attachVolumes(instances, attachments)
+	return instances, nil
+}
+
+// parseLegacyBools turns the "true"/"false" strings of the given attributes into booleans.
+func parseLegacyBools(attrs map[string]interface{}, names []string) {
+	for _, name := range names {
+		if v, ok := attrs[name].(string); ok {
+			attrs[name], _ = strconv.ParseBool(v)
+		}
+	}
+}
+
+// parseLegacyKey splits a version 3 resource key such as "data.aws_instance.web.1"
+// into its mode, type, name and count index (nil when the resource has no count).
+func parseLegacyKey(key string) (mode, resourceType, name string, index interface{}) {
+	mode = "managed"
+	parts := strings.Split(key, ".")
+	if parts[0] == "data" {
+		mode = "data"
+		parts = parts[1:]
+	}
+	if len(parts) < 2 {
+		return mode, "", "", nil
+	}
+
+	resourceType, name = parts[0], parts[1]
+	if len(parts) > 2 {
+		if i, err := strconv.Atoi(parts[2]); err == nil {
+			index = i
+		}
+	}
+
+	return mode, resourceType, name, index
+}
+
+// legacyModuleAddress turns a version 3 module path such as ["root", "app", "db"] into "module.app.module.db".
+func legacyModuleAddress(path []string) string {
+	var parts []string
+	for _, name := range path {
+		if name == "root" && len(parts) == 0 {
+			continue
+		}
+		parts = append(parts, "module."+name)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// expandFlatmap rebuilds nested values from version 3 flatmap attributes:
+// "tags.%" marks a map whose entries are "tags.<key>", and "vpc_security_group_ids.#"
+// marks a list or set whose elements are "<name>.<index or hash>[.<attribute>]".
+func expandFlatmap(flat map[string]string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range flat {
+		name, _, nested := strings.Cut(key, ".")
+		if !nested {
+			result[name] = value
+			continue
+		}
+		if _, done := result[name]; done {
+			continue
+		}
+
+		if _, ok := flat[name+".#"]; ok {
+			result[name] = expandFlatList(flat, name)
+		} else if _, ok := flat[name+".%"]; ok {
+			result[name] = expandFlatMap(flat, name)
+		}
+	}
+
+	return result
+}
+
+// expandFlatList collects the elements of the flatmap list or set stored under prefix.
+func expandFlatList(flat map[string]string, prefix string) []interface{} {
+	elements := make(map[string]map[string]string)
+	scalars := make(map[string]string)
+	for key, value := range flat {
+		rest, ok := strings.CutPrefix(key, prefix+".")
+		if !ok || rest == "#" {
+			continue
+		}
+		token, sub, nested := strings.Cut(rest, ".")
+		if !nested {
+			scalars[token] = value
+			continue
+		}
+		if elements[token] == nil {
+			elements[token] = make(map[string]string)
+		}
+		elements[token][sub] = value
+	}
+
+	tokens := make([]string, 0, len(scalars)+len(elements))
+	for token := range scalars {
+		tokens = append(tokens, token)
+	}
+	for token := range elements {
+		tokens = append(tokens, token)
+	}
+	sortFlatTokens(tokens)
+
+	list := make([]interface{}, 0, len(tokens))
+	for _, token := range tokens {
+		if value, ok := scalars[token]; ok {
+			list = append(list, value)
+			continue
+		}
+		list = append(list, expandFlatmap(elements[token]))
+	}
+
+	return list
+}
+
+// expandFlatMap collects the entries of the flatmap map stored under prefix.
+// Keys may contain dots (e.g. kubernetes.io/cluster tags), so everything after the prefix is the key.
+func expandFlatMap(flat map[string]string, prefix string) map[string]interface{} {
+	m := make(map[string]interface{})
+	for key, value := range flat {
+		rest, ok := strings.CutPrefix(key, prefix+".")
+		if !ok || rest == "%" {
+			continue
+		}
+		m[rest] = value
+	}
+
+	return m
+}
+
+// sortFlatTokens orders list indexes numerically so "10" comes after "2". Set hashes are numeric too,
+// which keeps the order stable between runs.
+func sortFlatTokens(tokens []string) {
+	sort.Slice(tokens, func(i, j int) bool {
+		a, errA := strconv.Atoi(tokens[i])
+		b, errB := strconv.Atoi(tokens[j])
+		if errA != nil || errB != nil {
+			return tokens[i] < tokens[j]
+		}
+		return a < b
+	})
+}
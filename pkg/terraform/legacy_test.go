@@ -0,0 +1,121 @@
+package terraform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+const legacyState = `{
+	"version": 3,
+	"terraform_version": "0.11.14",
+	"serial": 7,
+	"modules": [
+		{
+			"path": ["root"],
+			"resources": {
+				"aws_instance.web": {
+					"type": "aws_instance",
+					"primary": {
+						"id": "i-web",
+						"attributes": {
+							"id": "i-web",
+							"ami": "ami-123",
+							"instance_type": "t2.micro",
+							"monitoring": "true",
+							"tags.%": "2",
+							"tags.Name": "web",
+							"tags.kubernetes.io/cluster": "owned",
+							"vpc_security_group_ids.#": "2",
+							"vpc_security_group_ids.1234567": "sg-1",
+							"vpc_security_group_ids.987": "sg-2",
+							"root_block_device.#": "1",
+							"root_block_device.0.volume_id": "vol-1",
+							"root_block_device.0.volume_size": "8"
+						}
+					}
+				},
+				"data.aws_instance.bastion": {
+					"type": "aws_instance",
+					"primary": {"id": "i-bastion", "attributes": {"id": "i-bastion"}}
+				},
+				"aws_s3_bucket.logs": {
+					"type": "aws_s3_bucket",
+					"primary": {"id": "logs", "attributes": {"id": "logs"}}
+				}
+			}
+		},
+		{
+			"path": ["root", "workers"],
+			"resources": {
+				"aws_instance.worker.1": {
+					"type": "aws_instance",
+					"primary": {"id": "i-worker-1", "attributes": {"instance_type": "t2.small"}}
+				}
+			}
+		}
+	]
+}`
+
+func TestParseStateData_LegacyV3(t *testing.T) {
+	got, err := parseStateData(zerolog.Nop(), []byte(legacyState), false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	assert.Equal(t, &common.EC2Instance{
+		InstanceID:          "i-web",
+		Address:             "aws_instance.web",
+		InstanceType:        "t2.micro",
+		ImageID:             "ami-123",
+		Monitoring:          true,
+		Tags:                map[string]string{"Name": "web", "kubernetes.io/cluster": "owned"},
+		SecurityGroups:      []string{"sg-2", "sg-1"},
+		BlockDeviceMappings: []common.BlockDeviceMapping{{VolumeID: "vol-1", VolumeSize: 8}},
+	}, got[0])
+
+	assert.Equal(t, "i-worker-1", got[1].InstanceID)
+	assert.Equal(t, "module.workers.aws_instance.worker[1]", got[1].Address)
+	assert.Equal(t, "t2.small", got[1].InstanceType)
+
+	got, err = parseStateData(zerolog.Nop(), []byte(legacyState), true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, "data.aws_instance.bastion", got[1].Address)
+	assert.True(t, got[1].ObservedOnly)
+}
+
+func TestParseStateData_UnsupportedVersion(t *testing.T) {
+	_, err := parseStateData(zerolog.Nop(), []byte(`{"version": 2, "modules": []}`), false)
+	assert.ErrorIs(t, err, common.ErrUnsupportedStateVersion)
+
+	var versionErr *common.StateVersionError
+	assert.True(t, errors.As(err, &versionErr))
+	assert.Equal(t, 2, versionErr.Version)
+
+	_, err = parseStateData(zerolog.Nop(), []byte(`{"version": 3, "modules": [{"path": ["root"]}]}`), false)
+	assert.ErrorIs(t, err, common.ErrTerraformInstanceMissing)
+}
+
+func TestExpandFlatmap(t *testing.T) {
+	got := expandFlatmap(map[string]string{
+		"id":         "x",
+		"list.#":     "3",
+		"list.0":     "a",
+		"list.2":     "c",
+		"list.10":    "z",
+		"nested.#":   "1",
+		"nested.0.a": "1",
+		"empty.%":    "0",
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"id":     "x",
+		"list":   []interface{}{"a", "c", "z"},
+		"nested": []interface{}{map[string]interface{}{"a": "1"}},
+		"empty":  map[string]interface{}{},
+	}, got)
+}
@@ -2,88 +2,520 @@
 package terraform
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"os"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/rs/zerolog"
 
 	"github.com/odetolakehinde/drift-checker/pkg/common"
 )
 
 // Parser defines a facade for Terraform state parsing.
+//
+// Load fetches state from a location through the StateSource registered for its
+// scheme (file paths, "-" for stdin, http(s)://, s3://, tfc://, ...) and parses it.
+// LoadAll merges several states, expanding globs and directories of state files.
+// LoadPlan does the same as Load for `terraform show -json` output of a saved plan.
 type Parser interface {
-	Load(path string) ([]*common.EC2Instance, error)
+	Load(ctx context.Context, location string) ([]*common.EC2Instance, error)
+	LoadAll(ctx context.Context, locations []string) ([]*common.EC2Instance, []common.StateConflict, error)
+	LoadPlan(ctx context.Context, location string) ([]common.PlannedChange, error)
+	Parse(data []byte) ([]*common.EC2Instance, error)
+	RegisterSource(scheme string, source StateSource)
+	SetIncludeDataSources(include bool)
 }
 
 type stateParser struct {
-	logger zerolog.Logger
+	logger             zerolog.Logger
+	sources            map[string]StateSource
+	includeDataSources bool
 }
 
+// NewParser creates a Parser with the built-in file, stdin and http(s) sources registered.
 func NewParser(_ context.Context, logger zerolog.Logger) Parser {
 	return &stateParser{
-		logger: logger.With().Str(common.LogStrLayer, "terraform").Logger(),
+		logger:  logger.With().Str(common.LogStrLayer, "terraform").Logger(),
+		sources: defaultSources(),
 	}
 }
 
-func (p *stateParser) Load(path string) ([]*common.EC2Instance, error) {
+// RegisterSource makes source responsible for locations with the given URI scheme,
+// replacing any source previously registered for it. It is not safe to call concurrently with Load.
+func (p *stateParser) RegisterSource(scheme string, source StateSource) {
+	p.sources[strings.ToLower(scheme)] = source
+}
+
+// SetIncludeDataSources controls whether data "aws_instance" lookups are returned.
+//
+// Data sources describe infrastructure this state does not own, so they are
+// skipped by default. When included, they are marked as ObservedOnly.
+func (p *stateParser) SetIncludeDataSources(include bool) {
+	p.includeDataSources = include
+}
+
+// Load fetches raw state from location and parses it.
+func (p *stateParser) Load(ctx context.Context, location string) ([]*common.EC2Instance, error) {
 	log := p.logger.With().
-		Str(common.LogStrMethod, "Load - parseTerraformState").
-		Str("path", path).
+		Str(common.LogStrMethod, "Load - parseStateData").
+		Str("location", location).
 		Logger()
-	return parseTerraformState(log, path)
+
+	// stream the state when the source allows it, large states then never sit in memory whole
+	if stream, ok := p.sources[schemeOf(location)].(StreamSource); ok {
+		r, err := stream.Open(ctx, location)
+		if err != nil {
+			log.Err(err).Msg("failed to open state")
+			return nil, err
+		}
+		defer func(r io.ReadCloser) {
+			_ = r.Close()
+		}(r)
+
+		return parseStateReader(log, r, p.includeDataSources)
+	}
+
+	data, err := p.fetch(ctx, log, location)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStateData(log, data, p.includeDataSources)
 }
 
-// parseTerraformState parses a Terraform state file and extracts EC2Instance values.
-func parseTerraformState(log zerolog.Logger, stateFilePath string) ([]*common.EC2Instance, error) {
-	data, err := os.ReadFile(stateFilePath)
+// LoadPlan fetches saved plan JSON from location and parses the changes planned for existing instances.
+func (p *stateParser) LoadPlan(ctx context.Context, location string) ([]common.PlannedChange, error) {
+	log := p.logger.With().
+		Str(common.LogStrMethod, "LoadPlan - parsePlanData").
+		Str("location", location).
+		Logger()
+
+	data, err := p.fetch(ctx, log, location)
 	if err != nil {
-		log.Err(err).Msg("failed to read state file")
-		return nil, common.ErrStateFileNotProvided
+		return nil, err
 	}
 
-	var state common.TerraformState
-	if err = json.Unmarshal(data, &state); err != nil {
+	return parsePlanData(log, data)
+}
+
+// fetch reads raw data from location through the source registered for its scheme.
+func (p *stateParser) fetch(ctx context.Context, log zerolog.Logger, location string) ([]byte, error) {
+	scheme := schemeOf(location)
+	source, ok := p.sources[scheme]
+	if !ok {
+		log.Error().Str("scheme", scheme).Msg("no state source registered for scheme")
+		return nil, common.ErrUnsupportedStateSource
+	}
+
+	data, err := source.Fetch(ctx, location)
+	if err != nil {
+		log.Err(err).Str("scheme", scheme).Msg("failed to fetch state")
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Parse extracts EC2Instance values from raw Terraform state, e.g. state fetched from a remote backend.
+func (p *stateParser) Parse(data []byte) ([]*common.EC2Instance, error) {
+	log := p.logger.With().Str(common.LogStrMethod, "Parse - parseStateData").Logger()
+	return parseStateData(log, data, p.includeDataSources)
+}
+
+// parseStateData parses the raw content of a Terraform state and extracts EC2Instance values.
+// See parseStateReader for the formats accepted.
+func parseStateData(log zerolog.Logger, data []byte, includeDataSources bool) ([]*common.EC2Instance, error) {
+	return parseStateReader(log, bytes.NewReader(data), includeDataSources)
+}
+
+// parseStateReader streams a Terraform state and extracts EC2Instance values.
+// Raw state files (version 4, or legacy version 3) and `terraform show -json` output are accepted.
+// Data sources are skipped unless includeDataSources is set.
+//
+// Version 4 states are decoded one resource at a time and only aws_instance and
+// aws_volume_attachment resources have their attributes decoded, so very large
+// states are never held in memory whole.
+func parseStateReader(log zerolog.Logger, r io.Reader, includeDataSources bool) ([]*common.EC2Instance, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		// an empty file, e.g. a workspace nothing was ever applied to, holds no instances rather than being corrupt
+		if errors.Is(err, io.EOF) {
+			log.Error().Msg("state file is empty")
+			return nil, common.ErrTerraformInstanceMissing
+		}
 		log.Err(err).Msg("unable to marshal or parse state file - it is invalid")
 		return nil, common.ErrInvalidStateFile
 	}
 
-	var instances []*common.EC2Instance
+	var (
+		version       int
+		hasResources  bool
+		instances     []*common.EC2Instance
+		attachments   []volumeAttachment
+		resourceCount int
+		legacy        common.LegacyTerraformState
+		show          common.TerraformShowOutput
+	)
 
-	if len(state.Resources) == 0 {
-		log.Err(err).Msg("no terraform resources found in state file")
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			log.Err(err).Msg("unable to marshal or parse state file - it is invalid")
+			return nil, common.ErrInvalidStateFile
+		}
+
+		switch key {
+		case "version":
+			err = dec.Decode(&version)
+		case "format_version":
+			err = dec.Decode(&show.FormatVersion)
+		case "values":
+			err = dec.Decode(&show.Values)
+		case "modules":
+			err = dec.Decode(&legacy.Modules)
+		case "resources":
+			hasResources = true
+			resourceCount, err = decodeResources(dec, func(res common.TerraformStateResource) {
+				if res.Type == volumeAttachmentType {
+					for _, inst := range res.Instances {
+						attachments = append(attachments, volumeAttachmentFrom(inst.Attributes))
+					}
+					return
+				}
+				instances = append(instances, resourceInstances(res, includeDataSources)...)
+			})
+		default:
+			err = skipValue(dec)
+		}
+		if err != nil {
+			log.Err(err).Str("key", fmt.Sprint(key)).Msg("unable to marshal or parse state file - it is invalid")
+			return nil, common.ErrInvalidStateFile
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		log.Err(err).Msg("unable to marshal or parse state file - it is invalid")
+		return nil, common.ErrInvalidStateFile
+	}
+
+	// only `terraform show -json` output carries a format_version
+	if show.FormatVersion != "" {
+		return parseShowOutput(log, show, includeDataSources)
+	}
+
+	// a missing version is read as the current (v4) layout
+	switch version {
+	case 0, 4:
+	case 3:
+		return parseLegacyState(log, legacy, includeDataSources)
+	default:
+		log.Error().Int("version", version).Msg("unsupported state version")
+		return nil, &common.StateVersionError{Version: version}
+	}
+
+	if !hasResources || resourceCount == 0 {
+		log.Error().Msg("no terraform resources found in state file")
 		return nil, common.ErrTerraformInstanceMissing
 	}
 
-	for _, res := range state.Resources {
-		if res.Type != "aws_instance" {
+	attachVolumes(instances, attachments)
+	return instances, nil
+}
+
+// decodeResources streams the resources array of a version 4 state, calling fn for
+// every resource of a decodedResourceTypes type, and returns how many resources of any type were read.
+func decodeResources(dec *json.Decoder, fn func(res common.TerraformStateResource)) (int, error) {
+	if err := expectDelim(dec, '['); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for dec.More() {
+		res, err := decodeResource(dec)
+		if err != nil {
+			return count, err
+		}
+		count++
+		if decodedResourceTypes[res.Type] {
+			fn(res)
+		}
+	}
+
+	return count, expectDelim(dec, ']')
+}
+
+// decodeResource decodes a single resource object. The instances of any resource
+// not in decodedResourceTypes are skipped without decoding their attributes.
+func decodeResource(dec *json.Decoder) (common.TerraformStateResource, error) {
+	var res common.TerraformStateResource
+	if err := expectDelim(dec, '{'); err != nil {
+		return res, err
+	}
+
+	// terraform writes the type before the instances, but be lenient with hand-edited states
+	var deferred json.RawMessage
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return res, err
+		}
+
+		switch key {
+		case "module":
+			err = dec.Decode(&res.Module)
+		case "mode":
+			err = dec.Decode(&res.Mode)
+		case "type":
+			err = dec.Decode(&res.Type)
+		case "name":
+			err = dec.Decode(&res.Name)
+		case "instances":
+			switch {
+			case decodedResourceTypes[res.Type]:
+				err = dec.Decode(&res.Instances)
+			case res.Type == "":
+				err = dec.Decode(&deferred)
+			default:
+				err = skipValue(dec)
+			}
+		default:
+			err = skipValue(dec)
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if deferred != nil && decodedResourceTypes[res.Type] {
+		if err := json.Unmarshal(deferred, &res.Instances); err != nil {
+			return res, err
+		}
+	}
+
+	return res, expectDelim(dec, '}')
+}
+
+// resourceInstances maps the instances of an aws_instance resource to EC2Instance values.
+func resourceInstances(res common.TerraformStateResource, includeDataSources bool) []*common.EC2Instance {
+	observedOnly := res.Mode == "data"
+	if observedOnly && !includeDataSources {
+		return nil
+	}
+
+	instances := make([]*common.EC2Instance, 0, len(res.Instances))
+	for _, inst := range res.Instances {
+		ec2Inst := instanceFromAttributes(inst.Attributes)
+		ec2Inst.Address = common.ResourceAddress(res.Module, res.Mode, res.Type, res.Name, inst.IndexKey)
+		ec2Inst.ObservedOnly = observedOnly
+		ec2Inst.Sensitive = sensitiveFields(inst.SensitiveAttributes)
+
+		instances = append(instances, ec2Inst)
+	}
+
+	return instances
+}
+
+// expectDelim reads the next token and fails unless it is the given delimiter.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+
+	return nil
+}
+
+// skipValue consumes the next JSON value without decoding it.
+func skipValue(dec *json.Decoder) error {
+	var skipped json.RawMessage
+	return dec.Decode(&skipped)
+}
+
+// volumeAttachmentType is the resource type attaching an EBS volume to an instance outside its own blocks.
+const volumeAttachmentType = "aws_volume_attachment"
+
+// decodedResourceTypes are the resource types whose instances the streaming parser decodes.
+var decodedResourceTypes = map[string]bool{
+	"aws_instance":       true,
+	volumeAttachmentType: true,
+}
+
+// volumeAttachment is an aws_volume_attachment resource from the state.
+type volumeAttachment struct {
+	InstanceID string
+	DeviceName string
+	VolumeID   string
+}
+
+// volumeAttachmentFrom maps the attributes of an aws_volume_attachment resource.
+func volumeAttachmentFrom(attr map[string]interface{}) volumeAttachment {
+	return volumeAttachment{
+		InstanceID: common.ToString(attr["instance_id"]),
+		DeviceName: common.ToString(attr["device_name"]),
+		VolumeID:   common.ToString(attr["volume_id"]),
+	}
+}
+
+// attachVolumes adds the volumes attached through aws_volume_attachment resources to the block
+// device mappings of their instances, so they match what DescribeInstances reports. A device
+// already listed by the instance (refreshed into ebs_block_device, for instance) is not added twice.
+func attachVolumes(instances []*common.EC2Instance, attachments []volumeAttachment) {
+	byID := make(map[string]*common.EC2Instance, len(instances))
+	for _, inst := range instances {
+		if !inst.ObservedOnly {
+			byID[inst.InstanceID] = inst
+		}
+	}
+
+	for _, att := range attachments {
+		inst, ok := byID[att.InstanceID]
+		if !ok {
 			continue
 		}
+		if slices.ContainsFunc(inst.BlockDeviceMappings, func(bdm common.BlockDeviceMapping) bool {
+			return bdm.DeviceName == att.DeviceName
+		}) {
+			continue
+		}
+		inst.BlockDeviceMappings = append(inst.BlockDeviceMappings, common.BlockDeviceMapping{
+			DeviceName: att.DeviceName,
+			VolumeID:   att.VolumeID,
+			Attached:   true,
+		})
+	}
+}
+
+// attributeFields maps aws_instance attribute names to the drift field names they are compared as.
+var attributeFields = map[string]string{
+	"ami":                    "image_id",
+	"instance_type":          "instance_type",
+	"key_name":               "key_name",
+	"subnet_id":              "subnet_id",
+	"vpc_security_group_ids": "security_groups",
+	"tags":                   "tags",
+	"tags_all":               "tags",
+	"iam_instance_profile":   "iam_instance_profile",
+	"monitoring":             "monitoring",
+	"architecture":           "architecture",
+	"virtualization_type":    "virtualization_type",
+	"root_block_device":      "block_device_mappings",
+	"ebs_block_device":       "block_device_mappings",
+}
+
+// instanceFromAttributes maps the attributes of an aws_instance resource to an EC2Instance.
+func instanceFromAttributes(attr map[string]interface{}) *common.EC2Instance {
+	inst := &common.EC2Instance{
+		InstanceID:          common.ToString(attr["id"]),
+		AccountID:           accountOf(attr["arn"]),
+		InstanceType:        common.ToString(attr["instance_type"]),
+		ImageID:             common.ToString(attr["ami"]),
+		KeyName:             common.ToString(attr["key_name"]),
+		AvailabilityZone:    common.ToString(attr["availability_zone"]),
+		PrivateIPAddress:    common.ToString(attr["private_ip"]),
+		PublicIPAddress:     common.ToString(attr["public_ip"]),
+		SubnetID:            common.ToString(attr["subnet_id"]),
+		IamInstanceProfile:  common.ToString(attr["iam_instance_profile"]),
+		Monitoring:          common.ToBool(attr["monitoring"]),
+		Architecture:        common.ToString(attr["architecture"]),
+		VirtualizationType:  common.ToString(attr["virtualization_type"]),
+		Tags:                common.ConvertToStringMap(attr["tags"]),
+		SecurityGroups:      common.ConvertToStringSlice(attr["vpc_security_group_ids"]),
+		BlockDeviceMappings: append(common.ExtractBlockDevices(attr["root_block_device"]), common.ExtractBlockDevices(attr["ebs_block_device"])...),
+		EphemeralBlocks:     common.ExtractEphemeralBlockDevices(attr["ephemeral_block_device"]),
+	}
+
+	// tags_all only exists from AWS provider v3.38 on, older states leave the comparison to tags
+	if tagsAll, ok := attr["tags_all"]; ok && tagsAll != nil {
+		inst.TagsAll = common.ConvertToStringMap(tagsAll)
+	}
+
+	return inst
+}
+
+// accountOf returns the account ID of an instance ARN, or "" when the state does not record a valid one.
+func accountOf(v interface{}) string {
+	parsed, err := arn.Parse(common.ToString(v))
+	if err != nil {
+		return ""
+	}
+	return parsed.AccountID
+}
+
+// flaggedFields returns the drift fields flagged in a structure that mirrors an
+// instance's attributes with true at flagged leaves, such as after_unknown,
+// after_sensitive or sensitive_values. It returns nil when nothing is flagged.
+func flaggedFields(flags interface{}) map[string]bool {
+	attrs, ok := flags.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var fields map[string]bool
+	for attr, value := range attrs {
+		field, ok := attributeFields[attr]
+		if ok && containsTrue(value) {
+			if fields == nil {
+				fields = make(map[string]bool)
+			}
+			fields[field] = true
+		}
+	}
+
+	return fields
+}
 
-		for _, inst := range res.Instances {
-			attr := inst.Attributes
-
-			ec2Inst := &common.EC2Instance{
-				InstanceID:          common.ToString(attr["id"]),
-				InstanceType:        common.ToString(attr["instance_type"]),
-				ImageID:             common.ToString(attr["ami"]),
-				KeyName:             common.ToString(attr["key_name"]),
-				AvailabilityZone:    common.ToString(attr["availability_zone"]),
-				PrivateIPAddress:    common.ToString(attr["private_ip"]),
-				PublicIPAddress:     common.ToString(attr["public_ip"]),
-				SubnetID:            common.ToString(attr["subnet_id"]),
-				IamInstanceProfile:  common.ToString(attr["iam_instance_profile"]),
-				Monitoring:          common.ToBool(attr["monitoring"]),
-				Architecture:        common.ToString(attr["architecture"]),
-				VirtualizationType:  common.ToString(attr["virtualization_type"]),
-				Tags:                common.ConvertToStringMap(attr["tags"]),
-				SecurityGroups:      common.ConvertToStringSlice(attr["vpc_security_group_ids"]),
-				BlockDeviceMappings: common.ExtractBlockDevices(attr["root_block_device"]),
+// sensitiveFields returns the drift fields covered by the paths of a state's
+// sensitive_attributes, e.g. [[{"type":"get_attr","value":"tags"}, ...]].
+// A path reaching into an attribute makes the whole field sensitive.
+func sensitiveFields(paths []interface{}) map[string]bool {
+	var fields map[string]bool
+	for _, path := range paths {
+		steps, ok := path.([]interface{})
+		if !ok || len(steps) == 0 {
+			continue
+		}
+		step, ok := steps[0].(map[string]interface{})
+		if !ok || step["type"] != "get_attr" {
+			continue
+		}
+		if field, ok := attributeFields[common.ToString(step["value"])]; ok {
+			if fields == nil {
+				fields = make(map[string]bool)
 			}
+			fields[field] = true
+		}
+	}
+
+	return fields
+}
 
-			instances = append(instances, ec2Inst)
+// containsTrue reports whether a flag structure marks anything.
+// Nested blocks and collections mirror the attribute's shape with true at flagged leaves.
+func containsTrue(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case []interface{}:
+		for _, item := range val {
+			if containsTrue(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range val {
+			if containsTrue(item) {
+				return true
+			}
 		}
 	}
 
-	return instances, nil
+	return false
 }
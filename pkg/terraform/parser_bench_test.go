@@ -0,0 +1,117 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// benchmarkResources is the size of the generated state. Every 20th resource is an aws_instance.
+const benchmarkResources = 100_000
+
+// writeLargeState generates a version 4 state with benchmarkResources resources and returns its path and size.
+func writeLargeState(b *testing.B) (string, int64) {
+	b.Helper()
+
+	path := filepath.Join(b.TempDir(), "large.tfstate")
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	enc := json.NewEncoder(f)
+	_, _ = f.WriteString(`{"version":4,"terraform_version":"1.7.5","serial":1,"lineage":"bench","outputs":{},"resources":[`)
+	for i := 0; i < benchmarkResources; i++ {
+		if i > 0 {
+			_, _ = f.WriteString(",")
+		}
+
+		res := common.TerraformStateResource{Mode: "managed", Type: "aws_security_group_rule", Name: fmt.Sprintf("rule_%d", i)}
+		attrs := map[string]interface{}{
+			"id":                fmt.Sprintf("sgrule-%d", i),
+			"type":              "ingress",
+			"from_port":         443,
+			"to_port":           443,
+			"protocol":          "tcp",
+			"cidr_blocks":       []string{"10.0.0.0/16", "10.1.0.0/16"},
+			"security_group_id": "sg-0123456789abcdef0",
+			"description":       "generated for benchmarking the state parser",
+		}
+		if i%20 == 0 {
+			res.Type, res.Name = "aws_instance", fmt.Sprintf("web_%d", i)
+			attrs = map[string]interface{}{
+				"id":                     fmt.Sprintf("i-%017d", i),
+				"ami":                    "ami-0123456789abcdef0",
+				"instance_type":          "t3.micro",
+				"subnet_id":              "subnet-0123456789abcdef0",
+				"vpc_security_group_ids": []string{"sg-0123456789abcdef0"},
+				"tags":                   map[string]string{"Name": res.Name, "Env": "bench"},
+				"root_block_device":      []map[string]interface{}{{"device_name": "/dev/xvda", "volume_id": "vol-0123"}},
+			}
+		}
+		res.Instances = []common.TerraformStateInstance{{Attributes: attrs}}
+
+		if err := enc.Encode(res); err != nil {
+			b.Fatal(err)
+		}
+	}
+	_, _ = f.WriteString(`]}`)
+
+	info, err := f.Stat()
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	return path, info.Size()
+}
+
+// BenchmarkLoad_100kResources measures the streaming parser reading a large state from disk.
+func BenchmarkLoad_100kResources(b *testing.B) {
+	path, size := writeLargeState(b)
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		instances, err := p.Load(context.Background(), path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(instances) != benchmarkResources/20 {
+			b.Fatalf("got %d instances", len(instances))
+		}
+	}
+}
+
+// BenchmarkUnmarshalWhole_100kResources is the baseline: reading the whole file and
+// unmarshalling every resource, which is what the parser did before streaming.
+func BenchmarkUnmarshalWhole_100kResources(b *testing.B) {
+	path, size := writeLargeState(b)
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var state common.TerraformState
+		if err := json.Unmarshal(data, &state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -28,6 +29,7 @@ func TestParseTerraformState(t *testing.T) {
 							{
 								"attributes": {
 									"id": "i-abc123",
+									"arn": "arn:aws:ec2:us-east-1:123456789012:instance/i-abc123",
 									"ami": "ami-xyz",
 									"instance_type": "t3.micro",
 									"key_name": "my-key",
@@ -60,6 +62,8 @@ func TestParseTerraformState(t *testing.T) {
 			wantInst: []*common.EC2Instance{
 				{
 					InstanceID:         "i-abc123",
+					AccountID:          "123456789012",
+					Address:            "aws_instance.example",
 					InstanceType:       "t3.micro",
 					ImageID:            "ami-xyz",
 					KeyName:            "my-key",
@@ -79,6 +83,38 @@ func TestParseTerraformState(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "module with count",
+			content: `{
+				"resources": [
+					{
+						"module": "module.web",
+						"mode": "managed",
+						"type": "aws_instance",
+						"name": "app",
+						"instances": [
+							{"index_key": 0, "attributes": {"id": "i-0"}},
+							{"index_key": 1, "attributes": {"id": "i-1"}}
+						]
+					}
+				]
+			}`,
+			wantErr: false,
+			wantInst: []*common.EC2Instance{
+				{
+					InstanceID:          "i-0",
+					Address:             "module.web.aws_instance.app[0]",
+					Tags:                map[string]string{},
+					BlockDeviceMappings: []common.BlockDeviceMapping{},
+				},
+				{
+					InstanceID:          "i-1",
+					Address:             "module.web.aws_instance.app[1]",
+					Tags:                map[string]string{},
+					BlockDeviceMappings: []common.BlockDeviceMapping{},
+				},
+			},
+		},
 		{
 			name:    "invalid json",
 			content: `{not valid`,
@@ -107,7 +143,7 @@ func TestParseTerraformState(t *testing.T) {
 				return
 			}
 
-			got, err := parseTerraformState(zerolog.Nop(), tmpFile.Name())
+			got, err := NewParser(context.Background(), zerolog.Nop()).Load(context.Background(), tmpFile.Name())
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -117,3 +153,116 @@ func TestParseTerraformState(t *testing.T) {
 		})
 	}
 }
+
+func TestStateParser_Parse(t *testing.T) {
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	got, err := p.Parse([]byte(`{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-1","instance_type":"t3.micro"}}]}]}`))
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "i-1", got[0].InstanceID)
+	assert.Equal(t, "t3.micro", got[0].InstanceType)
+
+	_, err = p.Parse([]byte(`{not valid`))
+	assert.ErrorIs(t, err, common.ErrInvalidStateFile)
+}
+
+func TestParseStateData_DataSources(t *testing.T) {
+	content := []byte(`{
+		"resources": [
+			{"mode": "managed", "type": "aws_instance", "name": "app", "instances": [{"attributes": {"id": "i-managed"}}]},
+			{"mode": "data", "type": "aws_instance", "name": "bastion", "instances": [{"attributes": {"id": "i-shared"}}]}
+		]
+	}`)
+
+	got, err := parseStateData(zerolog.Nop(), content, false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "i-managed", got[0].InstanceID)
+
+	got, err = parseStateData(zerolog.Nop(), content, true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.False(t, got[0].ObservedOnly)
+	assert.True(t, got[1].ObservedOnly)
+	assert.Equal(t, "data.aws_instance.bastion", got[1].Address)
+}
+
+func TestParseStateData_Streaming(t *testing.T) {
+	// instances listed before the type, and unrelated resources with nested values to skip
+	content := []byte(`{
+		"version": 4,
+		"outputs": {"ip": {"value": {"nested": [1, 2, {"a": "b"}]}}},
+		"resources": [
+			{"instances": [{"attributes": {"id": "i-late"}}], "mode": "managed", "type": "aws_instance", "name": "late"},
+			{"mode": "managed", "type": "aws_s3_bucket", "name": "logs", "instances": [{"attributes": {"tags": {"a": ["x"]}}}]},
+			{"mode": "managed", "type": "aws_instance", "name": "web", "instances": [{"attributes": {"id": "i-web"}}]}
+		],
+		"check_results": null
+	}`)
+
+	got, err := parseStateData(zerolog.Nop(), content, false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "i-late", got[0].InstanceID)
+	assert.Equal(t, "aws_instance.late", got[0].Address)
+	assert.Equal(t, "i-web", got[1].InstanceID)
+
+	_, err = parseStateData(zerolog.Nop(), []byte(`{"version": 4, "resources": [{"type": "aws_instance"`), false)
+	assert.ErrorIs(t, err, common.ErrInvalidStateFile)
+
+	_, err = parseStateData(zerolog.Nop(), []byte(`{"version": 4, "resources": []}`), false)
+	assert.ErrorIs(t, err, common.ErrTerraformInstanceMissing)
+}
+
+func TestParseStateData_TagsAll(t *testing.T) {
+	got, err := parseStateData(zerolog.Nop(), []byte(`{"resources":[{"type":"aws_instance","name":"web","instances":[
+		{"attributes":{"id":"i-1","tags":{"Name":"web"},"tags_all":{"Name":"web","Env":"prod"}}},
+		{"attributes":{"id":"i-2","tags":{"Name":"api"}}}
+	]}]}`), false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, map[string]string{"Name": "web"}, got[0].Tags)
+	assert.Equal(t, map[string]string{"Name": "web", "Env": "prod"}, got[0].TagsAll)
+	assert.Nil(t, got[1].TagsAll, "states without tags_all fall back to tags")
+}
+
+func TestParseStateData_SensitiveAttributes(t *testing.T) {
+	got, err := parseStateData(zerolog.Nop(), []byte(`{"version":4,"resources":[{"type":"aws_instance","name":"web","instances":[{
+		"attributes":{"id":"i-1","tags":{"Secret":"x"},"key_name":"k"},
+		"sensitive_attributes":[
+			[{"type":"get_attr","value":"tags"},{"type":"index","value":{"value":"Secret","type":"string"}}],
+			[{"type":"get_attr","value":"user_data"}]
+		]
+	}]}]}`), false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, map[string]bool{"tags": true}, got[0].Sensitive)
+}
+
+func TestParseStateData_BlockDevices(t *testing.T) {
+	got, err := parseStateData(zerolog.Nop(), []byte(`{"version":4,"resources":[
+		{"mode":"managed","type":"aws_volume_attachment","name":"data","instances":[
+			{"attributes":{"instance_id":"i-1","device_name":"/dev/sdg","volume_id":"vol-attached"}},
+			{"attributes":{"instance_id":"i-1","device_name":"/dev/sdf","volume_id":"vol-ebs"}},
+			{"attributes":{"instance_id":"i-other","device_name":"/dev/sdh","volume_id":"vol-x"}}
+		]},
+		{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{
+			"id":"i-1",
+			"root_block_device":[{"device_name":"/dev/xvda","volume_id":"vol-root"}],
+			"ebs_block_device":[{"device_name":"/dev/sdf","volume_id":"vol-ebs"}],
+			"ephemeral_block_device":[
+				{"device_name":"/dev/sdb","virtual_name":"ephemeral0"},
+				{"device_name":"/dev/sdc","no_device":true}
+			]
+		}}]}
+	]}`), false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, []common.BlockDeviceMapping{
+		{DeviceName: "/dev/xvda", VolumeID: "vol-root"},
+		{DeviceName: "/dev/sdf", VolumeID: "vol-ebs"},
+		{DeviceName: "/dev/sdg", VolumeID: "vol-attached", Attached: true},
+	}, got[0].BlockDeviceMappings)
+	assert.Equal(t, []common.BlockDeviceMapping{{DeviceName: "/dev/sdb", VirtualName: "ephemeral0"}}, got[0].EphemeralBlocks)
+}
@@ -0,0 +1,68 @@
+package terraform
+
+import (
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// parsePlanData parses `terraform show -json` output of a saved plan and returns the
+// changes planned for existing aws_instance resources. Instances the plan creates are
+// skipped since there is nothing live to compare them with yet, and so are data sources.
+func parsePlanData(log zerolog.Logger, data []byte) ([]common.PlannedChange, error) {
+	var plan common.TerraformPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		log.Err(err).Msg("unable to parse plan - it is invalid")
+		return nil, common.ErrInvalidPlanFile
+	}
+	if plan.FormatVersion == "" || plan.ResourceChanges == nil {
+		log.Error().Msg("input is not the JSON output of a saved plan")
+		return nil, common.ErrInvalidPlanFile
+	}
+
+	var changes []common.PlannedChange
+	for _, rc := range plan.ResourceChanges {
+		if rc.Type != "aws_instance" || rc.Mode == "data" || rc.Change.Before == nil {
+			continue
+		}
+
+		before := instanceFromAttributes(rc.Change.Before)
+		before.Address = rc.Address
+		before.Sensitive = flaggedFields(rc.Change.BeforeSensitive)
+
+		change := common.PlannedChange{
+			Action: planAction(rc.Change.Actions),
+			Before: before,
+		}
+
+		// deleted instances have no after values
+		if rc.Change.After != nil {
+			after := instanceFromAttributes(rc.Change.After)
+			after.Address = rc.Address
+			if after.InstanceID == "" {
+				after.InstanceID = before.InstanceID
+			}
+			after.Unknown = flaggedFields(rc.Change.AfterUnknown)
+			after.Sensitive = flaggedFields(rc.Change.AfterSensitive)
+			change.After = after
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// planAction collapses the actions list of a resource change into a single action.
+func planAction(actions []string) string {
+	if len(actions) == 2 {
+		return common.PlanActionReplace
+	}
+	if len(actions) == 1 {
+		return actions[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,96 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+const planOutput = `{
+	"format_version": "1.2",
+	"resource_changes": [
+		{
+			"address": "aws_instance.web",
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "web",
+			"change": {
+				"actions": ["update"],
+				"before": {"id": "i-web", "instance_type": "t3.micro", "tags": {"Name": "web"}},
+				"after": {"id": "i-web", "instance_type": "t3.large", "tags": {"Name": "web"}},
+				"after_unknown": {"public_ip": true, "tags": {}, "root_block_device": [{"volume_id": true}]},
+				"before_sensitive": false,
+				"after_sensitive": {"key_name": true, "tags": {}}
+			}
+		},
+		{
+			"address": "module.app.aws_instance.worker[0]",
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "worker",
+			"index": 0,
+			"change": {
+				"actions": ["delete", "create"],
+				"before": {"id": "i-worker", "ami": "ami-1"},
+				"after": {"ami": "ami-2"},
+				"after_unknown": {"id": true}
+			}
+		},
+		{
+			"address": "aws_instance.new",
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "new",
+			"change": {"actions": ["create"], "before": null, "after": {"ami": "ami-2"}}
+		},
+		{
+			"address": "aws_s3_bucket.logs",
+			"mode": "managed",
+			"type": "aws_s3_bucket",
+			"name": "logs",
+			"change": {"actions": ["no-op"], "before": {"id": "logs"}, "after": {"id": "logs"}}
+		}
+	]
+}`
+
+func TestParsePlanData(t *testing.T) {
+	got, err := parsePlanData(zerolog.Nop(), []byte(planOutput))
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	assert.Equal(t, common.PlanActionUpdate, got[0].Action)
+	assert.Equal(t, "t3.micro", got[0].Before.InstanceType)
+	assert.Equal(t, "t3.large", got[0].After.InstanceType)
+	assert.Equal(t, "aws_instance.web", got[0].After.Address)
+	assert.Equal(t, map[string]bool{"block_device_mappings": true}, got[0].After.Unknown)
+	assert.Nil(t, got[0].Before.Sensitive)
+	assert.Equal(t, map[string]bool{"key_name": true}, got[0].After.Sensitive)
+
+	assert.Equal(t, common.PlanActionReplace, got[1].Action)
+	assert.Equal(t, "i-worker", got[1].After.InstanceID, "unknown ID falls back to the existing instance")
+	assert.Equal(t, "module.app.aws_instance.worker[0]", got[1].Before.Address)
+}
+
+func TestParsePlanData_Invalid(t *testing.T) {
+	_, err := parsePlanData(zerolog.Nop(), []byte(`not json`))
+	assert.ErrorIs(t, err, common.ErrInvalidPlanFile)
+
+	// a raw state is not a plan
+	_, err = parsePlanData(zerolog.Nop(), []byte(singleInstanceState))
+	assert.ErrorIs(t, err, common.ErrInvalidPlanFile)
+}
+
+func TestLoadPlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	assert.NoError(t, os.WriteFile(path, []byte(planOutput), 0o600))
+
+	got, err := NewParser(context.Background(), zerolog.Nop()).LoadPlan(context.Background(), path)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+}
@@ -0,0 +1,62 @@
+package terraform
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// parseShowOutput extracts EC2Instance values from decoded `terraform show -json` output,
+// walking the root module and all nested child modules.
+func parseShowOutput(log zerolog.Logger, show common.TerraformShowOutput, includeDataSources bool) ([]*common.EC2Instance, error) {
+	// an empty state has no values at all
+	if show.Values == nil {
+		log.Error().Msg("no terraform resources found in show output")
+		return nil, common.ErrTerraformInstanceMissing
+	}
+
+	resources := moduleResources(show.Values.RootModule)
+	if len(resources) == 0 {
+		log.Error().Msg("no terraform resources found in show output")
+		return nil, common.ErrTerraformInstanceMissing
+	}
+
+	var (
+		instances   []*common.EC2Instance
+		attachments []volumeAttachment
+	)
+	for _, res := range resources {
+		if res.Type == volumeAttachmentType && res.Mode != "data" {
+			attachments = append(attachments, volumeAttachmentFrom(res.Values))
+			continue
+		}
+		if res.Type != "aws_instance" {
+			continue
+		}
+
+		observedOnly := res.Mode == "data"
+		if observedOnly && !includeDataSources {
+			continue
+		}
+
+		ec2Inst := instanceFromAttributes(res.Values)
+		ec2Inst.Address = res.Address
+		ec2Inst.ObservedOnly = observedOnly
+		ec2Inst.Sensitive = flaggedFields(res.SensitiveValues)
+
+		instances = append(instances, ec2Inst)
+	}
+
+	attachVolumes(instances, attachments)
+	return instances, nil
+}
+
+// moduleResources flattens the resources of module and all of its descendants, depth first.
+func moduleResources(module common.TerraformModule) []common.TerraformResource {
+	resources := append([]common.TerraformResource{}, module.Resources...)
+	for _, child := range module.ChildModules {
+		resources = append(resources, moduleResources(child)...)
+	}
+
+	return resources
+}
@@ -0,0 +1,100 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+const showOutput = `{
+	"format_version": "1.0",
+	"terraform_version": "1.7.5",
+	"values": {
+		"root_module": {
+			"resources": [
+				{
+					"address": "aws_instance.web",
+					"mode": "managed",
+					"type": "aws_instance",
+					"name": "web",
+					"values": {
+						"id": "i-root",
+						"instance_type": "t3.micro",
+						"vpc_security_group_ids": ["sg-1"],
+						"tags": {"Name": "web"}
+					},
+					"sensitive_values": {"tags": {"Name": true}, "vpc_security_group_ids": [false]}
+				},
+				{
+					"address": "data.aws_instance.bastion",
+					"mode": "data",
+					"type": "aws_instance",
+					"name": "bastion",
+					"values": {"id": "i-bastion"}
+				}
+			],
+			"child_modules": [
+				{
+					"address": "module.app",
+					"resources": [
+						{
+							"address": "module.app.aws_s3_bucket.logs",
+							"mode": "managed",
+							"type": "aws_s3_bucket",
+							"name": "logs",
+							"values": {"id": "logs"}
+						}
+					],
+					"child_modules": [
+						{
+							"address": "module.app.module.workers",
+							"resources": [
+								{
+									"address": "module.app.module.workers.aws_instance.worker[0]",
+									"mode": "managed",
+									"type": "aws_instance",
+									"name": "worker",
+									"index": 0,
+									"values": {"id": "i-worker-0", "instance_type": "t3.small"}
+								}
+							]
+						}
+					]
+				}
+			]
+		}
+	}
+}`
+
+func TestParseStateData_ShowOutput(t *testing.T) {
+	got, err := parseStateData(zerolog.Nop(), []byte(showOutput), false)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	assert.Equal(t, "i-root", got[0].InstanceID)
+	assert.Equal(t, "aws_instance.web", got[0].Address)
+	assert.Equal(t, "t3.micro", got[0].InstanceType)
+	assert.Equal(t, []string{"sg-1"}, got[0].SecurityGroups)
+	assert.Equal(t, map[string]string{"Name": "web"}, got[0].Tags)
+	assert.Equal(t, map[string]bool{"tags": true}, got[0].Sensitive)
+
+	assert.Equal(t, "i-worker-0", got[1].InstanceID)
+	assert.Equal(t, "module.app.module.workers.aws_instance.worker[0]", got[1].Address)
+
+	got, err = parseStateData(zerolog.Nop(), []byte(showOutput), true)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, "data.aws_instance.bastion", got[1].Address)
+	assert.True(t, got[1].ObservedOnly)
+}
+
+func TestParseStateData_EmptyShowOutput(t *testing.T) {
+	_, err := parseStateData(zerolog.Nop(), []byte(`{"format_version":"1.0","terraform_version":"1.7.5"}`), false)
+	assert.ErrorIs(t, err, common.ErrTerraformInstanceMissing)
+
+	_, err = parseStateData(zerolog.Nop(), []byte(`{"format_version":"1.0","values":{"root_module":{}}}`), false)
+	assert.ErrorIs(t, err, common.ErrTerraformInstanceMissing)
+}
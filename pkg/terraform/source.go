@@ -0,0 +1,149 @@
+package terraform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+// StateSource fetches raw Terraform state from a location such as a file path or URL.
+type StateSource interface {
+	Fetch(ctx context.Context, location string) ([]byte, error)
+}
+
+// StreamSource is implemented by state sources that can hand out state as a stream,
+// so that large states are parsed without first being read into memory.
+type StreamSource interface {
+	Open(ctx context.Context, location string) (io.ReadCloser, error)
+}
+
+// StateSourceFunc adapts an ordinary function to the StateSource interface.
+type StateSourceFunc func(ctx context.Context, location string) ([]byte, error)
+
+// Fetch calls f(ctx, location).
+func (f StateSourceFunc) Fetch(ctx context.Context, location string) ([]byte, error) {
+	return f(ctx, location)
+}
+
+// Well-known schemes of the built-in state sources.
+const (
+	SchemeFile  = "file"
+	SchemeStdin = "stdin"
+	SchemeHTTP  = "http"
+	SchemeHTTPS = "https"
+	SchemeS3    = "s3"
+	SchemeTFC   = "tfc"
+)
+
+// schemeOf returns the URI scheme of a state location. Plain paths, including
+// Windows drive letters (C:\...), are treated as files and "-" as stdin.
+func schemeOf(location string) string {
+	if location == "-" {
+		return SchemeStdin
+	}
+
+	u, err := url.Parse(location)
+	if err != nil || len(u.Scheme) <= 1 {
+		return SchemeFile
+	}
+
+	return strings.ToLower(u.Scheme)
+}
+
+// fileSource reads state from the local filesystem.
+type fileSource struct{}
+
+func (fileSource) Fetch(_ context.Context, location string) ([]byte, error) {
+	data, err := os.ReadFile(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return nil, common.ErrStateFileNotProvided
+	}
+
+	return data, nil
+}
+
+func (fileSource) Open(_ context.Context, location string) (io.ReadCloser, error) {
+	f, err := os.Open(strings.TrimPrefix(location, "file://"))
+	if err != nil {
+		return nil, common.ErrStateFileNotProvided
+	}
+
+	return f, nil
+}
+
+// readerSource reads state from a stream, typically stdin, e.g. `terraform state pull | drift-checker --state-url -`.
+type readerSource struct {
+	reader io.Reader
+}
+
+func (s readerSource) Fetch(_ context.Context, _ string) ([]byte, error) {
+	data, err := io.ReadAll(s.reader)
+	if err != nil {
+		return nil, common.ErrStateFetchFailure
+	}
+
+	return data, nil
+}
+
+func (s readerSource) Open(_ context.Context, _ string) (io.ReadCloser, error) {
+	return io.NopCloser(s.reader), nil
+}
+
+// httpSource downloads state with a plain GET request, e.g. from an artifact store or the Terraform HTTP backend.
+type httpSource struct {
+	client *http.Client
+}
+
+func (s httpSource) Fetch(ctx context.Context, location string) ([]byte, error) {
+	body, err := s.Open(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(body)
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, common.ErrStateFetchFailure
+	}
+
+	return data, nil
+}
+
+func (s httpSource) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, common.ErrStateFetchFailure
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, common.ErrStateFetchFailure
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, common.ErrStateFetchFailure
+	}
+
+	return resp.Body, nil
+}
+
+// defaultSources returns the sources every parser knows about out of the box.
+func defaultSources() map[string]StateSource {
+	httpSrc := httpSource{client: &http.Client{Timeout: 30 * time.Second}}
+
+	return map[string]StateSource{
+		SchemeFile:  fileSource{},
+		SchemeStdin: readerSource{reader: os.Stdin},
+		SchemeHTTP:  httpSrc,
+		SchemeHTTPS: httpSrc,
+	}
+}
@@ -0,0 +1,94 @@
+package terraform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/odetolakehinde/drift-checker/pkg/common"
+)
+
+const singleInstanceState = `{"resources":[{"type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-1"}}]}]}`
+
+func TestSchemeOf(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"file/tf.tfstate", SchemeFile},
+		{"/abs/path/terraform.tfstate", SchemeFile},
+		{`C:\states\prod.tfstate`, SchemeFile},
+		{"file:///tmp/prod.tfstate", SchemeFile},
+		{"-", SchemeStdin},
+		{"s3://bucket/key", SchemeS3},
+		{"tfc://acme/prod", SchemeTFC},
+		{"HTTPS://example.com/state", SchemeHTTPS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, schemeOf(tt.in))
+		})
+	}
+}
+
+func TestReaderSource(t *testing.T) {
+	p := &stateParser{
+		logger:  zerolog.Nop(),
+		sources: map[string]StateSource{SchemeStdin: readerSource{reader: strings.NewReader(singleInstanceState)}},
+	}
+
+	got, err := p.Load(context.Background(), "-")
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestHTTPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prod.tfstate" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(singleInstanceState))
+	}))
+	defer server.Close()
+
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	got, err := p.Load(context.Background(), server.URL+"/prod.tfstate")
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	_, err = p.Load(context.Background(), server.URL+"/missing.tfstate")
+	assert.ErrorIs(t, err, common.ErrStateFetchFailure)
+
+	var src StateSource = httpSource{client: server.Client()}
+	_, ok := src.(StreamSource)
+	assert.True(t, ok, "http state must be streamed")
+	data, err := src.Fetch(context.Background(), server.URL+"/prod.tfstate")
+	assert.NoError(t, err)
+	assert.Equal(t, singleInstanceState, string(data))
+}
+
+func TestRegisterSource(t *testing.T) {
+	p := NewParser(context.Background(), zerolog.Nop())
+
+	_, err := p.Load(context.Background(), "gs://bucket/state")
+	assert.ErrorIs(t, err, common.ErrUnsupportedStateSource)
+
+	var requested string
+	p.RegisterSource("gs", StateSourceFunc(func(_ context.Context, location string) ([]byte, error) {
+		requested = location
+		return []byte(singleInstanceState), nil
+	}))
+
+	got, err := p.Load(context.Background(), "gs://bucket/state")
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "gs://bucket/state", requested)
+}